@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"power4/internal/game"
+)
+
+// benchPositions are the move strings searched when no positions file is given, from the opening to the late middlegame
+var benchPositions = []string{
+	"",
+	"4",
+	"44",
+	"4453",
+	"444343",
+	"43445566",
+	"4444373355",
+	"3456543",
+	"1234567",
+	"44444422233355",
+}
+
+// main searches every benchmark position with each thread count for the same time and reports nodes per second and depth reached
+func main() {
+	threadList := flag.String("threads", "1,"+strconv.Itoa(runtime.NumCPU()), "comma-separated thread counts to compare")
+	moveTime := flag.Duration("movetime", time.Second, "search time per position")
+	positions := flag.String("positions", "", "file with one move string per line, the built-in positions when empty")
+	flag.Parse()
+
+	moves := benchPositions
+	if *positions != "" {
+		var err error
+		if moves, err = readPositions(*positions); err != nil {
+			log.Fatal(err)
+		}
+	}
+	var counts []int
+	for _, f := range strings.Split(*threadList, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || n <= 0 {
+			log.Fatalf("invalid thread count %q", f)
+		}
+		counts = append(counts, n)
+	}
+
+	fmt.Printf("%-8s %12s %12s %10s\n", "threads", "nodes", "nodes/s", "avg depth")
+	for _, n := range counts {
+		bot := game.SearchBot{Label: "bench", Budget: *moveTime, Threads: n}
+		var nodes int64
+		var elapsed time.Duration
+		depth := 0
+		for _, m := range moves {
+			g, err := game.GameFromMoves(m)
+			if err != nil {
+				log.Fatalf("position %q: %v", m, err)
+			}
+			p, err := game.PositionFromBoard(&g.Board, g.NextPlayer)
+			if err != nil {
+				log.Fatalf("position %q: %v", m, err)
+			}
+			_, info := bot.Move(context.Background(), p)
+			nodes += info.Nodes
+			elapsed += info.Elapsed
+			depth += info.Depth
+		}
+		nps := int64(float64(nodes) / max(elapsed.Seconds(), 1e-9))
+		fmt.Printf("%-8d %12d %12d %10.1f\n", n, nodes, nps, float64(depth)/float64(len(moves)))
+	}
+}
+
+// readPositions reads the move strings of a positions file, skipping blank lines and # comments
+func readPositions(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return out, sc.Err()
+}
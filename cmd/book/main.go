@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"power4/internal/game"
+)
+
+// main generates an opening book by searching every move of every position up to the requested number of discs
+func main() {
+	plies := flag.Int("plies", 4, "book positions have fewer discs than this")
+	depth := flag.Int("depth", 12, "search depth in plies for each move")
+	moveTime := flag.Duration("movetime", 2*time.Second, "search time limit for each move")
+	out := flag.String("out", "data/book.txt", "output file")
+	flag.Parse()
+
+	// stops early on interrupt and still writes the positions already scored
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	book, err := game.BuildBook(ctx, game.NewPosition(game.Player1), *plies, *depth, *moveTime, func(done int) {
+		if done%25 == 0 {
+			log.Printf("%d positions, %s", done, time.Since(start).Round(time.Second))
+		}
+	})
+	if err != nil {
+		log.Printf("interrupted: %v", err)
+	}
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := book.WriteTo(f); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d positions to %s in %s", book.Len(), *out, time.Since(start).Round(time.Second))
+}
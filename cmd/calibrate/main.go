@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"power4/internal/game"
+)
+
+// main plays the Elo bot at each target rating against the anchor levels and reports the rating it actually performs at
+func main() {
+	targets := flag.String("targets", "600,800,1000,1200,1400,1650", "comma-separated ratings to measure")
+	games := flag.Int("games", 40, "games against each anchor, rounded up to an even number")
+	concurrency := flag.Int("concurrency", runtime.NumCPU(), "games played at the same time")
+	openingPlies := flag.Int("opening-plies", 2, "start from every balanced opening of this many plies, 0 for the empty board")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var openings [][]int
+	if *openingPlies > 0 {
+		openings = game.BalancedOpenings(ctx, *openingPlies, 8, 60)
+	}
+
+	// anchors are played weakest first
+	ids := make([]string, 0, len(game.AnchorElo))
+	for id := range game.AnchorElo {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return game.AnchorElo[ids[i]] < game.AnchorElo[ids[j]] })
+	ratings := make([]int, len(ids))
+	for i, id := range ids {
+		ratings[i] = game.AnchorElo[id]
+	}
+
+	n := *games + *games%2
+	start := time.Now()
+	for _, t := range strings.Split(*targets, ",") {
+		elo, err := strconv.Atoi(strings.TrimSpace(t))
+		if err != nil {
+			log.Fatalf("bad target %q", t)
+		}
+		bot, _ := game.LookupBot(game.EloBotID(elo))
+		results := make([]game.MatchResult, len(ids))
+		for i, id := range ids {
+			results[i] = game.PlayMatch(ctx, game.BotPicker(bot), game.LevelPicker(game.BotLevel(id)), openings, n, *concurrency, nil)
+			fmt.Printf("%-6s vs %-7s (%d): +%d =%d -%d, %.1f%%\n", bot.Name(), id, ratings[i], results[i].Wins, results[i].Draws, results[i].Losses, 100*results[i].Score())
+		}
+		perf := game.PerformanceRating(ratings, results)
+		fmt.Printf("target %d performs at %.0f (%+.0f)\n\n", elo, perf, perf-float64(elo))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	log.Printf("done in %s", time.Since(start).Round(time.Second))
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"power4/internal/game"
+)
+
+// main runs the reference engine: it speaks the external engine protocol on stdin/stdout
+// and answers with the built-in minimax search
+func main() {
+	threads := flag.Int("threads", 0, "threads each search uses, 0 for one per CPU")
+	flag.Parse()
+	game.SetSearchThreads(*threads)
+
+	in := bufio.NewScanner(os.Stdin)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	// reply writes one line and flushes it so the server sees it at once
+	reply := func(format string, args ...any) {
+		fmt.Fprintf(out, format+"\n", args...)
+		_ = out.Flush()
+	}
+
+	// the current position, empty with Player1 to move until told otherwise
+	pos := game.NewPosition(game.Player1)
+
+	// searches run in the background so stop can interrupt them
+	var cancel context.CancelFunc
+	var done chan struct{}
+	wait := func() {
+		if done != nil {
+			<-done
+			cancel()
+			done, cancel = nil, nil
+		}
+	}
+
+	for in.Scan() {
+		f := strings.Fields(in.Text())
+		if len(f) == 0 {
+			continue
+		}
+		switch f[0] {
+		case "c4i":
+			wait()
+			reply("id name Power 4 reference minimax")
+			reply("c4iok")
+		case "isready":
+			wait()
+			reply("readyok")
+		case "newgame":
+			wait()
+			pos = game.NewPosition(game.Player1)
+		case "position":
+			wait()
+			if len(f) < 3 {
+				continue
+			}
+			b, err := game.ParseGrid(f[1])
+			side, ok := game.ParseCellChar(f[2][0])
+			if err != nil || !ok {
+				continue
+			}
+			p, err := game.PositionFromBoard(&b, side)
+			if err != nil {
+				continue
+			}
+			pos = p
+		case "go":
+			wait()
+			bot := goBot(f[1:])
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			done = make(chan struct{})
+			go func(p game.Position, finished chan struct{}) {
+				defer close(finished)
+				col, info := bot.Move(ctx, p)
+				pv := make([]string, 0, len(info.PV))
+				for _, c := range info.PV {
+					pv = append(pv, strconv.Itoa(c+1))
+				}
+				reply("info depth %d score %d nodes %d pv %s", info.Depth, info.Score, info.Nodes, strings.Join(pv, " "))
+				reply("bestmove %d", col+1)
+			}(pos, done)
+		case "stop":
+			if cancel != nil {
+				cancel()
+			}
+			wait()
+		case "quit":
+			if cancel != nil {
+				cancel()
+			}
+			wait()
+			return
+		}
+	}
+	wait()
+}
+
+// goBot returns the engine for the arguments of a go command: a fixed depth or a move time
+func goBot(args []string) game.Bot {
+	budget := time.Second
+	for i := 0; i+1 < len(args); i += 2 {
+		n, err := strconv.Atoi(args[i+1])
+		if err != nil || n <= 0 {
+			continue
+		}
+		switch args[i] {
+		case "depth":
+			return game.MinimaxBot{Label: "minimax", Depth: n}
+		case "movetime":
+			budget = time.Duration(n) * time.Millisecond
+		}
+	}
+	return game.SearchBot{Label: "search", Budget: budget}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"power4/internal/game"
+)
+
+// main extracts win-in-N and only-move puzzles from self-play or stored games and writes them with a difficulty rating
+func main() {
+	gamesFile := flag.String("games", "", "file of stored games, one move string or exported \"Moves:\" line per game; self-play when empty")
+	count := flag.Int("count", 200, "self-play games to play")
+	level := flag.Int("level", 3, "difficulty level 1-6 of the self-play bots")
+	randomPlies := flag.Int("random-plies", 6, "random moves opening each self-play game")
+	minPlies := flag.Int("min-plies", 8, "fewest discs of a puzzle position")
+	minN := flag.Int("min-n", 2, "shortest win a win puzzle may ask for, in own moves")
+	maxN := flag.Int("max-n", 4, "longest win a win puzzle may ask for, in own moves")
+	perGame := flag.Int("per-game", 2, "most puzzles taken from one game")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed")
+	out := flag.String("out", "data/puzzles.txt", "output file")
+	flag.Parse()
+
+	// stops early on interrupt and still writes the puzzles already found
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	lines, err := sourceGames(*gamesFile, *count, *level, *randomPlies, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	start := time.Now()
+	seen := make(map[string]bool)
+	var puzzles []game.Puzzle
+	for i, moves := range lines {
+		found := 0
+		for ply := *minPlies; ply < len(moves) && found < *perGame && ctx.Err() == nil; ply++ {
+			prefix := moves[:ply]
+			if seen[prefix] {
+				continue
+			}
+			seen[prefix] = true
+			if pz, ok := game.FindPuzzle(ctx, prefix, *minN, *maxN); ok {
+				puzzles = append(puzzles, pz)
+				found++
+				// the next positions of the same line give the same win a move later
+				ply += 2*pz.N - 1
+			}
+		}
+		if (i+1)%25 == 0 {
+			log.Printf("%d games, %d puzzles, %s", i+1, len(puzzles), time.Since(start).Round(time.Second))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	sort.SliceStable(puzzles, func(i, j int) bool { return puzzles[i].Rating < puzzles[j].Rating })
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := game.WritePuzzles(f, puzzles); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d puzzles to %s in %s", len(puzzles), *out, time.Since(start).Round(time.Second))
+}
+
+// sourceGames returns the move strings of the stored games in path, or of count self-play games when path is empty
+func sourceGames(path string, count, level, randomPlies int, rng *rand.Rand) ([]string, error) {
+	if path == "" {
+		out := make([]string, 0, count)
+		for i := 0; i < count; i++ {
+			out = append(out, game.FormatMoves(game.MoveCols(game.SelfPlayGame(level, randomPlies, rng).Moves)))
+		}
+		return out, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var out []string
+	setup := false // the export being read started from a set-up position, so its moves do not replay from an empty board
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		text := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(text, "Setup:") {
+			setup = true
+			continue
+		}
+		if rest, ok := strings.CutPrefix(text, "Moves:"); ok {
+			text = strings.TrimSpace(rest)
+			if setup {
+				setup = false
+				continue
+			}
+		}
+		if _, err := game.ParseMoves(text); text != "" && err == nil {
+			out = append(out, text)
+		}
+	}
+	return out, sc.Err()
+}
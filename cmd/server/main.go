@@ -1,13 +1,35 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"net/http"
 	"power4/internal/app"
+	"power4/internal/game"
+	httphandler "power4/internal/http"
+	"runtime"
+	"time"
 )
 
 // main bootstraps the application and starts the HTTP server
 func main() {
+	// Reads how long timed bots may think per move
+	think := flag.Duration("bot-think", 2*time.Second, "wall-clock time a timed bot spends on each move")
+	// Reads whether bots vary their opening book moves
+	bookRandom := flag.Bool("book-random", true, "pick at random among equally good opening book moves")
+	// Reads how many threads a bot search uses, few so that concurrent games share the cores
+	threads := flag.Int("threads", 2, "threads each bot search uses, 0 for one per CPU")
+	// Reads how many bot searches may run at once, by default as many as fit the cores
+	searches := flag.Int("bot-searches", 0, "bot searches running at the same time, 0 for CPUs divided by threads")
+	flag.Parse()
+	httphandler.SetBotThinkTime(*think)
+	game.SetBookRandom(*bookRandom)
+	game.SetSearchThreads(*threads)
+	if *searches <= 0 {
+		*searches = runtime.NumCPU() / game.SearchThreads()
+	}
+	httphandler.SetBotSearchLimit(*searches)
+
 	// Boot returns the mux, a cleanup function, and an error if init fails
 	mux, err := app.Boot("data")
 	if err != nil {
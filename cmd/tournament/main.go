@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"time"
+
+	"power4/internal/game"
+)
+
+// main plays a self-play match between two bot configurations and reports the score, the Elo difference and an SPRT verdict
+func main() {
+	specA := flag.String("a", "5", "first engine: a difficulty level 1-6 played through ComputeBotMove, a registered bot id or elo:<rating>")
+	specB := flag.String("b", "4", "second engine, like -a")
+	games := flag.Int("games", 100, "games to play, rounded up to an even number so every opening is played with both colors")
+	concurrency := flag.Int("concurrency", runtime.NumCPU(), "games played at the same time")
+	threads := flag.Int("threads", 1, "threads each bot search uses")
+	openingPlies := flag.Int("opening-plies", 0, "start from every balanced opening of this many plies, 0 for the empty board")
+	balanceDepth := flag.Int("balance-depth", 8, "search depth used to keep balanced openings")
+	balanceMargin := flag.Int("balance-margin", 60, "largest evaluation an opening may keep for the side to move")
+	elo0 := flag.Float64("elo0", 0, "SPRT null hypothesis, Elo of -a over -b")
+	elo1 := flag.Float64("elo1", 10, "SPRT alternative hypothesis")
+	alpha := flag.Float64("alpha", 0.05, "SPRT false positive rate")
+	beta := flag.Float64("beta", 0.05, "SPRT false negative rate")
+	sprtStop := flag.Bool("sprt-stop", true, "stop as soon as SPRT accepts a hypothesis")
+	flag.Parse()
+
+	a, nameA, err := picker(*specA)
+	if err != nil {
+		log.Fatal(err)
+	}
+	b, nameB, err := picker(*specB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	game.SetSearchThreads(*threads)
+
+	// stops early on interrupt and still reports the games already played
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var openings [][]int
+	if *openingPlies > 0 {
+		openings = game.BalancedOpenings(ctx, *openingPlies, *balanceDepth, *balanceMargin)
+		if len(openings) == 0 {
+			log.Fatal("no balanced opening, raise -balance-margin")
+		}
+		log.Printf("%d balanced openings of %d plies", len(openings), *openingPlies)
+	}
+
+	n := *games + *games%2
+	start := time.Now()
+	log.Printf("%s vs %s, %d games", nameA, nameB, n)
+	res := game.PlayMatch(ctx, a, b, openings, n, *concurrency, func(r game.MatchResult) bool {
+		if r.Games()%10 == 0 {
+			log.Printf("%d games: +%d =%d -%d, LLR %.2f", r.Games(), r.Wins, r.Draws, r.Losses, r.LLR(*elo0, *elo1))
+		}
+		return *sprtStop && r.SPRT(*elo0, *elo1, *alpha, *beta) != 0
+	})
+
+	elo, margin := res.Elo()
+	lower, upper := game.SPRTBounds(*alpha, *beta)
+	verdict := "continue, no hypothesis accepted yet"
+	switch res.SPRT(*elo0, *elo1, *alpha, *beta) {
+	case 1:
+		verdict = fmt.Sprintf("H1 accepted, %s is at least %g Elo stronger", nameA, *elo1)
+	case -1:
+		verdict = fmt.Sprintf("H0 accepted, %s is not %g Elo stronger", nameA, *elo1)
+	}
+	fmt.Printf("%s vs %s after %d games in %s\n", nameA, nameB, res.Games(), time.Since(start).Round(time.Second))
+	fmt.Printf("Score: +%d =%d -%d, %.1f%%\n", res.Wins, res.Draws, res.Losses, 100*res.Score())
+	fmt.Printf("Elo difference: %+.1f ± %.1f (95%%)\n", elo, margin)
+	fmt.Printf("SPRT [%g, %g] LLR %.2f (%.2f, %.2f): %s\n", *elo0, *elo1, res.LLR(*elo0, *elo1), lower, upper, verdict)
+}
+
+// picker returns the move picker and display name of an engine spec: a difficulty level or a registered bot id
+func picker(spec string) (game.MovePicker, string, error) {
+	if level, err := strconv.Atoi(spec); err == nil {
+		if level < 1 || level > 6 {
+			return nil, "", fmt.Errorf("level %d out of range 1-6", level)
+		}
+		return game.LevelPicker(level), "level " + spec, nil
+	}
+	bot, ok := game.LookupBot(spec)
+	if !ok {
+		return nil, "", fmt.Errorf("unknown bot %q", spec)
+	}
+	return game.BotPicker(bot), bot.Name(), nil
+}
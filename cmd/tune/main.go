@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"math/rand"
+	"os"
+	"os/signal"
+	"time"
+
+	"power4/internal/game"
+)
+
+// main fits the evaluation weights to labelled positions and writes them where the server loads them at boot
+func main() {
+	source := flag.String("source", "selfplay", "how positions are labelled: selfplay for game outcomes, solver for exact results")
+	games := flag.Int("games", 500, "self-play games to play")
+	level := flag.Int("level", 3, "difficulty level 1-6 of the self-play bots")
+	randomPlies := flag.Int("random-plies", 6, "random moves opening each self-play game")
+	samples := flag.Int("samples", 2000, "positions the solver labels")
+	minPlies := flag.Int("min-plies", 16, "fewest discs of a position the solver labels")
+	scale := flag.Float64("scale", 100, "evaluation points per unit of log-odds")
+	seed := flag.Int64("seed", time.Now().UnixNano(), "random seed")
+	out := flag.String("out", "data/weights.txt", "output file")
+	flag.Parse()
+
+	// stops early on interrupt and still fits the positions already labelled
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	rng := rand.New(rand.NewSource(*seed))
+	start := time.Now()
+	var data []game.TuneSample
+	switch *source {
+	case "selfplay":
+		data = game.SelfPlaySamples(ctx, *games, *level, *randomPlies, rng, func(done int) {
+			if done%50 == 0 {
+				log.Printf("%d games, %s", done, time.Since(start).Round(time.Second))
+			}
+		})
+	case "solver":
+		data = game.SolverSamples(ctx, *samples, *minPlies, rng, func(done int) {
+			if done%100 == 0 {
+				log.Printf("%d positions, %s", done, time.Since(start).Round(time.Second))
+			}
+		})
+	default:
+		log.Fatalf("unknown source %q", *source)
+	}
+	if len(data) == 0 {
+		log.Fatal("no labelled positions")
+	}
+
+	w := game.TuneEvalWeights(data, *scale)
+	log.Printf("%d positions, log loss %.4f with the default weights, %.4f tuned",
+		len(data), game.EvalLogLoss(data, game.DefaultEvalWeights, *scale), game.EvalLogLoss(data, w, *scale))
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := w.WriteTo(f); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %+v to %s in %s", w, *out, time.Since(start).Round(time.Second))
+}
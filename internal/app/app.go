@@ -4,8 +4,10 @@ import (
 	"io/fs"
 	"log"
 	"net/http"
+	"os"
 	"power4"
 	"power4/internal/auth"
+	"power4/internal/game"
 	httphandler "power4/internal/http"
 )
 
@@ -36,6 +38,32 @@ func Boot(dataDir string) (*http.ServeMux, error) {
 		log.Printf("friends load error: %v", err)
 	}
 
+	// Registers external bot engines listed in engines.json
+	if err := game.RegisterEngines(dataDir + "/engines.json"); err != nil {
+		log.Printf("engines load error: %v", err)
+	}
+
+	// Loads the opening book used by the searching bots, if one was generated
+	if book, err := game.LoadBook(dataDir + "/book.txt"); err == nil {
+		game.SetOpeningBook(book)
+	} else if !os.IsNotExist(err) {
+		log.Printf("book load error: %v", err)
+	}
+
+	// Loads the evaluation weights written by cmd/tune, if the engines were tuned
+	if w, err := game.LoadEvalWeights(dataDir + "/weights.txt"); err == nil {
+		game.SetEvalWeights(w)
+	} else if !os.IsNotExist(err) {
+		log.Printf("weights load error: %v", err)
+	}
+
+	// Loads the puzzles written by cmd/puzzles, if any were generated
+	if ps, err := game.LoadPuzzles(dataDir + "/puzzles.txt"); err == nil {
+		httphandler.SetPuzzles(ps)
+	} else if !os.IsNotExist(err) {
+		log.Printf("puzzles load error: %v", err)
+	}
+
 	// Serves static assets from the embedded filesystem
 	staticFS, err := fs.Sub(power4.Content, "static")
 	if err != nil {
@@ -3,6 +3,7 @@ package auth
 import (
 	"encoding/json"
 	"errors"
+	"maps"
 	"os"
 	"path/filepath"
 	"sort"
@@ -16,14 +17,40 @@ import (
 )
 
 type User struct {
-	ID           string    // unique user id
-	Username     string    // chosen username
-	PasswordHash []byte    // bcrypt hash of the password
-	CreatedAt    time.Time // account creation time
-	Elo          int       // current Elo rating
-	Games        int       // total games played
-	Wins         int       // total wins
-	Losses       int       // total losses
+	ID           string         // unique user id
+	Username     string         // chosen username
+	PasswordHash []byte         // bcrypt hash of the password
+	CreatedAt    time.Time      // account creation time
+	Elo          int            // current Elo rating
+	Games        int            // total games played
+	Wins         int            // total wins
+	Losses       int            // total losses
+	MisereElo    int            // Elo rating in misère games, rated apart from Elo
+	MisereGames  int            // misère games played, also counted in Games
+	Ratings      map[string]int // Elo in the rating pools other than classic and misère, by pool
+	PuzzleElo    int            // puzzle rating, played against the rating of each puzzle tried
+	Puzzles      int            // puzzles tried
+	PuzzleSolves int            // puzzles solved
+}
+
+// rating pools kept in dedicated fields, every other pool lives in Ratings
+const (
+	PoolClassic = "classic"
+	PoolMisere  = "misere"
+)
+
+// Rating returns the Elo of u in the given rating pool, 1500 for pools u has not played yet
+func (u *User) Rating(pool string) int {
+	switch pool {
+	case PoolClassic:
+		return u.Elo
+	case PoolMisere:
+		return u.MisereElo
+	}
+	if r, ok := u.Ratings[pool]; ok {
+		return r
+	}
+	return 1500
 }
 
 type Store struct {
@@ -53,6 +80,14 @@ func Open(dir string) (*Store, error) {
 			return nil, err
 		}
 		for _, u := range users {
+			// starts the misère rating of users saved before it existed
+			if u.MisereElo == 0 {
+				u.MisereElo = 1500
+			}
+			// starts the puzzle rating of users saved before puzzles existed
+			if u.PuzzleElo == 0 {
+				u.PuzzleElo = 1500
+			}
 			s.byID[u.ID] = u
 			s.byName[strings.ToLower(u.Username)] = u
 		}
@@ -66,6 +101,7 @@ func snapshotUsers(s *Store) []*User {
 	out := make([]*User, 0, len(s.byID))
 	for _, u := range s.byID {
 		cp := *u
+		cp.Ratings = maps.Clone(u.Ratings)
 		out = append(out, &cp)
 	}
 	s.mu.RUnlock()
@@ -121,6 +157,8 @@ func (s *Store) Create(username, password string) (*User, error) {
 		PasswordHash: h,
 		CreatedAt:    time.Now(),
 		Elo:          1500,
+		MisereElo:    1500,
+		PuzzleElo:    1500,
 	}
 	s.byID[u.ID] = u
 	s.byName[lc] = u
@@ -164,43 +202,108 @@ func (s *Store) GetByID(id string) *User {
 	return s.byID[id]
 }
 
-// ApplyMatch applies a match result, updates Elo and stats, and saves the store
-func (s *Store) ApplyMatch(usernameA, usernameB string, scoreA float64, k int) error {
-	lca := strings.ToLower(usernameA)
-	lcb := strings.ToLower(usernameB)
+// ApplyPoolGame records a game between several players in the given pool, places[i] being the finishing place of usernames[i],
+// 1 the best: ratings move by pairwise Elo between every two players from their ratings before the game, K shared among the opponents,
+// and each player counts the game once, a win for a sole first place and a loss for any place behind the first, then saves the store
+func (s *Store) ApplyPoolGame(pool string, usernames []string, places []int, k int) error {
+	n := len(usernames)
+	if n < 2 || len(places) != n {
+		return errors.New("invalid game result")
+	}
 
 	s.mu.Lock()
-	ua := s.byName[lca]
-	ub := s.byName[lcb]
-	if ua == nil || ub == nil {
-		s.mu.Unlock()
-		return errors.New("user not found")
-	}
-
-	// calculates expected score and generated delta using Elo formula
-	ea := expected(ua.Elo, ub.Elo)
-	da := int(round(float64(k) * (scoreA - ea)))
-	db := -da
-
-	// updates ratings and stats
-	ua.Elo += da
-	ub.Elo += db
-	ua.Games++
-	ub.Games++
-	if scoreA > 0.5 {
-		ua.Wins++
-		ub.Losses++
-	} else if scoreA < 0.5 {
-		ub.Wins++
-		ua.Losses++
+	users := make([]*User, n)
+	for i, name := range usernames {
+		if users[i] = s.byName[strings.ToLower(name)]; users[i] == nil {
+			s.mu.Unlock()
+			return errors.New("user not found")
+		}
+	}
+
+	// sums the pairwise deltas before applying any, so the order of the pairs does not matter
+	kp := float64(k) / float64(n-1)
+	deltas := make([]float64, n)
+	firsts := 0
+	for i := range users {
+		if places[i] == 1 {
+			firsts++
+		}
+		for j := i + 1; j < n; j++ {
+			score := 0.5
+			if places[i] < places[j] {
+				score = 1
+			} else if places[i] > places[j] {
+				score = 0
+			}
+			d := kp * (score - expected(users[i].Rating(pool), users[j].Rating(pool)))
+			deltas[i] += d
+			deltas[j] -= d
+		}
+	}
+
+	// updates ratings and stats once per player
+	for i, u := range users {
+		u.addRating(pool, int(round(deltas[i])))
+		u.Games++
+		switch {
+		case places[i] == 1 && firsts == 1:
+			u.Wins++
+		case places[i] > 1:
+			u.Losses++
+		}
 	}
 	s.mu.Unlock()
 
 	return s.save()
 }
 
+// ApplyPuzzle updates the puzzle rating of a user who solved or failed a puzzle of the given rating, saves the store
+// and returns the rating change
+func (s *Store) ApplyPuzzle(username string, puzzleRating int, solved bool, k int) (int, error) {
+	s.mu.Lock()
+	u := s.byName[strings.ToLower(username)]
+	if u == nil {
+		s.mu.Unlock()
+		return 0, errors.New("user not found")
+	}
+
+	// scores the attempt like a game against the puzzle
+	score := 0.0
+	if solved {
+		score = 1
+		u.PuzzleSolves++
+	}
+	d := int(round(float64(k) * (score - expected(u.PuzzleElo, puzzleRating))))
+	u.PuzzleElo += d
+	u.Puzzles++
+	s.mu.Unlock()
+
+	return d, s.save()
+}
+
+// addRating adds delta to the Elo of u in the given pool, counting misère games apart
+func (u *User) addRating(pool string, delta int) {
+	switch pool {
+	case PoolClassic:
+		u.Elo += delta
+	case PoolMisere:
+		u.MisereElo += delta
+		u.MisereGames++
+	default:
+		if u.Ratings == nil {
+			u.Ratings = make(map[string]int)
+		}
+		u.Ratings[pool] = u.Rating(pool) + delta
+	}
+}
+
 // UsersByElo returns users filtered by query and sorted by Elo desc then username asc
 func (s *Store) UsersByElo(query string) []*User {
+	return s.UsersByRating(PoolClassic, query)
+}
+
+// UsersByRating returns users filtered by query and sorted by their rating in pool desc then username asc
+func (s *Store) UsersByRating(pool, query string) []*User {
 	q := strings.ToLower(strings.TrimSpace(query))
 
 	// copies matched users to avoid exposing internal pointers
@@ -209,17 +312,19 @@ func (s *Store) UsersByElo(query string) []*User {
 	for _, u := range s.byID {
 		if q == "" || strings.Contains(strings.ToLower(u.Username), q) {
 			cp := *u
+			cp.Ratings = maps.Clone(u.Ratings)
 			users = append(users, &cp)
 		}
 	}
 	s.mu.RUnlock()
 
-	// sorts by Elo then username
+	// sorts by rating then username
 	sort.Slice(users, func(i, j int) bool {
-		if users[i].Elo == users[j].Elo {
+		ri, rj := users[i].Rating(pool), users[j].Rating(pool)
+		if ri == rj {
 			return users[i].Username < users[j].Username
 		}
-		return users[i].Elo > users[j].Elo
+		return ri > rj
 	})
 	return users
 }
@@ -1,8 +1,11 @@
 package game
 
 import (
+	"context"
 	"math"
+	"math/bits"
 	"math/rand"
+	"sync"
 )
 
 // opponent returns the opposing player for p
@@ -14,126 +17,112 @@ func opponent(p Cell) Cell {
 }
 
 // validMoves returns columns that can accept a new piece
-func validMoves(b *Board) []int {
+func validMoves(p *Position) []int {
 	var out []int
 	for c := 0; c < Cols; c++ {
-		if b.Grid[0][c] == Empty {
+		if p.CanPlay(c) {
 			out = append(out, c)
 		}
 	}
 	return out
 }
 
-// apply tries to drop a piece in col and returns the new board and whether it succeeded
-func apply(b Board, col int, p Cell) (Board, bool) {
-	nb := b
-	_, err := AddPeon(&nb, col, p)
-	if err != nil {
-		return b, false
-	}
-	return nb, true
+// apply drops a piece in col for the player to move and returns the new position
+func apply(p Position, col int) Position {
+	p.Play(col)
+	return p
 }
 
 // terminalScore detects wins or draws and generates a score factoring in depth
-func terminalScore(b *Board, me Cell, depth int) (bool, int) {
-	if w, ok := IsGameWon(b); ok {
+func terminalScore(p *Position, me Cell, depth int) (bool, int) {
+	if w, ok := p.Winner(); ok {
+		// under misère the player who completed the line lost
+		if p.Misere {
+			w = opponent(w)
+		}
 		if w == me {
 			return true, 100000 - depth
 		}
 		return true, depth - 100000
 	}
-	if IsFull(b) {
+	if p.IsFull() {
 		return true, 0
 	}
 	return false, 0
 }
 
-// countWindow scores a 4‑cell window for me and penalizes threats by the opponent
-func countWindow(vals []Cell, me Cell) int {
-	opp := opponent(me)
-	meCount := 0
-	oppCount := 0
-	empty := 0
-	for _, v := range vals {
-		if v == me {
-			meCount++
-		} else if v == opp {
-			oppCount++
-		} else {
-			empty++
-		}
-	}
-	if meCount == 4 {
+// countWindow scores an n‑cell window holding mine discs for me and theirs for the opponent with the weights wt
+// under misère the weights flip sign since every line is a liability for its owner
+func countWindow(wt *EvalWeights, mine, theirs, n int, misere bool) int {
+	if misere {
+		return -countWindow(wt, mine, theirs, n, false)
+	}
+	empty := n - mine - theirs
+	if mine == n {
 		return 10000
 	}
-	if meCount == 3 && empty == 1 {
-		return 100
+	if mine == n-1 && empty == 1 {
+		return wt.Three
 	}
-	if meCount == 2 && empty == 2 {
-		return 10
+	if mine == n-2 && empty == 2 {
+		return wt.Two
 	}
-	if oppCount == 3 && empty == 1 {
-		return -120
+	if theirs == n-1 && empty == 1 {
+		return wt.OppThree
 	}
-	if oppCount == 2 && empty == 2 {
-		return -12
+	if theirs == n-2 && empty == 2 {
+		return wt.OppTwo
 	}
 	return 0
 }
 
-// eval generates a heuristic score for me favoring center control and potential lines
-func eval(b *Board, me Cell) int {
-	score := 0
-
-	// favors center column occupancy
-	centerCol := Cols / 2
-	centerCount := 0
-	for r := 0; r < Rows; r++ {
-		if b.Grid[r][centerCol] == me {
-			centerCount++
-		}
-	}
-	score += centerCount * 6
-
-	// scores horizontal windows
-	for r := 0; r < Rows; r++ {
-		for c := 0; c <= Cols-4; c++ {
-			score += countWindow([]Cell{b.Grid[r][c], b.Grid[r][c+1], b.Grid[r][c+2], b.Grid[r][c+3]}, me)
-		}
-	}
+// windows lists every 4‑cell line of the board as a bitmask
+var windows = genWindows()
 
-	// scores vertical windows
+// genWindows generates the masks of all horizontal, vertical and diagonal windows
+func genWindows() []uint64 {
+	var out []uint64
 	for c := 0; c < Cols; c++ {
-		for r := 0; r <= Rows-4; r++ {
-			score += countWindow([]Cell{b.Grid[r][c], b.Grid[r+1][c], b.Grid[r+2][c], b.Grid[r+3][c]}, me)
+		for r := 0; r < Rows; r++ {
+			for _, d := range [4][2]int{{0, 1}, {1, 0}, {1, 1}, {-1, 1}} {
+				er, ec := r+d[0]*(toWin-1), c+d[1]*(toWin-1)
+				if er < 0 || er >= Rows || ec >= Cols {
+					continue
+				}
+				var m uint64
+				for i := 0; i < toWin; i++ {
+					m |= cellBit(r+d[0]*i, c+d[1]*i)
+				}
+				out = append(out, m)
+			}
 		}
 	}
+	return out
+}
 
-	// scores diagonal down‑right windows
-	for r := 0; r <= Rows-4; r++ {
-		for c := 0; c <= Cols-4; c++ {
-			score += countWindow([]Cell{b.Grid[r][c], b.Grid[r+1][c+1], b.Grid[r+2][c+2], b.Grid[r+3][c+3]}, me)
-		}
+// eval generates a heuristic score for me favoring center control and potential lines, avoiding both under misère
+func eval(p *Position, me Cell) int {
+	wt := evalWeights.Load()
+	mine := p.Stones[me-1]
+	theirs := p.Stones[opponent(me)-1]
+
+	// favors center column occupancy, which joins the most lines
+	score := bits.OnesCount64(mine&colMask(Cols/2)) * wt.Center
+	if p.Misere {
+		score = -score
 	}
 
-	// scores diagonal up‑right windows
-	for r := 3; r < Rows; r++ {
-		for c := 0; c <= Cols-4; c++ {
-			score += countWindow([]Cell{b.Grid[r][c], b.Grid[r-1][c+1], b.Grid[r-2][c+2], b.Grid[r-3][c+3]}, me)
-		}
+	// scores every horizontal, vertical and diagonal window
+	for _, w := range windows {
+		score += countWindow(wt, bits.OnesCount64(mine&w), bits.OnesCount64(theirs&w), toWin, p.Misere)
 	}
 	return score
 }
 
-// immediateWin tries each valid move and returns the column that wins immediately or -1
-func immediateWin(b *Board, p Cell) int {
-	moves := validMoves(b)
-	for _, c := range moves {
-		nb, ok := apply(*b, c, p)
-		if !ok {
-			continue
-		}
-		if w, ok := IsGameWon(&nb); ok && w == p {
+// immediateWin returns the column that wins immediately for the player to move or -1
+func immediateWin(p *Position) int {
+	for _, c := range validMoves(p) {
+		if p.IsWinningMove(c) {
 			return c
 		}
 	}
@@ -172,23 +161,20 @@ func absInt(x int) int {
 }
 
 // minimax explores moves with alpha‑beta pruning and generates a heuristic score
-func minimax(b Board, depth int, alpha, beta int, maximizing bool, me Cell) int {
-	if term, sc := terminalScore(&b, me, depth); term {
+// once ctx is done the remaining subtrees get the static evaluation, checked away from the leaves to stay cheap
+func minimax(ctx context.Context, p Position, depth int, alpha, beta int, maximizing bool, me Cell) int {
+	if term, sc := terminalScore(&p, me, depth); term {
 		return sc
 	}
-	if depth == 0 {
-		return eval(&b, me)
+	if depth == 0 || depth > 2 && ctx.Err() != nil {
+		return eval(&p, me)
 	}
 
-	moves := orderMovesCenterFirst(validMoves(&b))
+	moves := orderMovesCenterFirst(validMoves(&p))
 	if maximizing {
 		maxEval := math.MinInt32
 		for _, c := range moves {
-			nb, ok := apply(b, c, me)
-			if !ok {
-				continue
-			}
-			e := minimax(nb, depth-1, alpha, beta, false, me)
+			e := minimax(ctx, apply(p, c), depth-1, alpha, beta, false, me)
 			if e > maxEval {
 				maxEval = e
 			}
@@ -203,13 +189,8 @@ func minimax(b Board, depth int, alpha, beta int, maximizing bool, me Cell) int
 	}
 
 	minEval := math.MaxInt32
-	opp := opponent(me)
 	for _, c := range moves {
-		nb, ok := apply(b, c, opp)
-		if !ok {
-			continue
-		}
-		e := minimax(nb, depth-1, alpha, beta, true, me)
+		e := minimax(ctx, apply(p, c), depth-1, alpha, beta, true, me)
 		if e < minEval {
 			minEval = e
 		}
@@ -224,8 +205,8 @@ func minimax(b Board, depth int, alpha, beta int, maximizing bool, me Cell) int
 }
 
 // pickRandom picks any valid move uniformly at random
-func pickRandom(b *Board) int {
-	ms := validMoves(b)
+func pickRandom(p *Position) int {
+	ms := validMoves(p)
 	if len(ms) == 0 {
 		return -1
 	}
@@ -233,23 +214,21 @@ func pickRandom(b *Board) int {
 }
 
 // pickGreedy tries to win now, blocks opponent wins, then picks the highest immediate eval
-func pickGreedy(b *Board, me Cell) int {
-	if c := immediateWin(b, me); c >= 0 {
+func pickGreedy(p *Position) int {
+	me := p.Next
+	if c := immediateWin(p); c >= 0 {
 		return c
 	}
-	opp := opponent(me)
-	if c := immediateWin(b, opp); c >= 0 {
+	opp := *p
+	opp.Next = opponent(me)
+	if c := immediateWin(&opp); c >= 0 {
 		return c
 	}
-	moves := validMoves(b)
 	best := -1
 	bestScore := math.MinInt32
 	center := Cols / 2
-	for _, c := range moves {
-		nb, ok := apply(*b, c, me)
-		if !ok {
-			continue
-		}
+	for _, c := range validMoves(p) {
+		nb := apply(*p, c)
 		sc := eval(&nb, me) - absInt(center-c)
 		if sc > bestScore {
 			bestScore = sc
@@ -260,37 +239,95 @@ func pickGreedy(b *Board, me Cell) int {
 }
 
 // pickMinimax generates a move using minimax at the requested depth
-func pickMinimax(b *Board, me Cell, depth int) int {
-	moves := orderMovesCenterFirst(validMoves(b))
+// the root moves are scored on up to SearchThreads goroutines, each with a full window so the pick does not depend on the split
+func pickMinimax(ctx context.Context, p *Position, depth int) int {
+	moves, scores := scoreRootMoves(ctx, p, depth)
 	best := -1
 	bestScore := math.MinInt32
-	for _, c := range moves {
-		nb, ok := apply(*b, c, me)
-		if !ok {
-			continue
-		}
-		score := minimax(nb, depth-1, math.MinInt32/2, math.MaxInt32/2, false, me)
-		if score > bestScore {
-			bestScore = score
+	for i, c := range moves {
+		if scores[i] > bestScore {
+			bestScore = scores[i]
 			best = c
 		}
 	}
 	return best
 }
 
-// ComputeBotMove generates a move based on difficulty level
+// scoreRootMoves returns the legal columns of p center first with their minimax score at depth for the player to move
+func scoreRootMoves(ctx context.Context, p *Position, depth int) ([]int, []int) {
+	me := p.Next
+	moves := orderMovesCenterFirst(validMoves(p))
+	scores := make([]int, len(moves))
+	parallelFor(len(moves), SearchThreads(), func(i int) {
+		scores[i] = minimax(ctx, apply(*p, moves[i]), depth-1, math.MinInt32/2, math.MaxInt32/2, false, me)
+	})
+	return moves, scores
+}
+
+// parallelFor calls f for every index below n on at most threads goroutines and waits for them
+func parallelFor(n, threads int, f func(i int)) {
+	if threads <= 1 || n <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, threads)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			f(i)
+			<-sem
+		}()
+	}
+	wg.Wait()
+}
+
+// solverMinMoves is the number of discs from which the solver answers within a turn
+const solverMinMoves = 12
+
+// ComputeBotMove generates a move with the registered bot of the given difficulty level
+// the searching levels play from the opening book before searching
 func ComputeBotMove(b *Board, who Cell, level int) int {
-	if level <= 1 {
-		return pickRandom(b)
+	// misère on the classic board gets the bitboard minimax, which honors it, one ply deeper per level
+	if b.Rules.IsClassicMisere() {
+		p, err := PositionFromBoard(b, who)
+		if err != nil {
+			return -1
+		}
+		if level <= 1 {
+			return pickRandom(&p)
+		}
+		return pickMinimax(context.Background(), &p, level+1)
+	}
+	// the bitboard bots only play the classic board, other rules get a grid minimax as deep as the level
+	if !b.Rules.IsClassic() {
+		return pickGridMinimax(b, who, max(1, min(level, gridMaxDepth)))
+	}
+	bot, ok := LookupBot(LevelBotID(level))
+	if !ok {
+		return -1
 	}
-	if level == 2 {
-		return pickGreedy(b, who)
+	p, err := PositionFromBoard(b, who)
+	if err != nil {
+		return -1
 	}
-	if level == 3 {
-		return pickMinimax(b, who, 3)
+	col, _ := bot.Move(context.Background(), p)
+	return col
+}
+
+// ComputeBotAction generates a drop or, under PopOut rules, possibly a pop with the bot of the given difficulty level
+// with power-ups it may also play one of the special discs left in stock
+func ComputeBotAction(b *Board, who Cell, stock Stock, level int) (MoveKind, int) {
+	if !b.Rules.PopOut && b.Rules.PowerUps == 0 {
+		return DropMove, ComputeBotMove(b, who, level)
 	}
-	if level == 4 {
-		return pickMinimax(b, who, 4)
+	a, ok := pickGridAction(b, who, max(1, min(level, gridMaxDepth)), true, stock)
+	if !ok {
+		return DropMove, -1
 	}
-	return pickMinimax(b, who, 5)
+	return a.kind, a.col
 }
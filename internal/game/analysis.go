@@ -0,0 +1,121 @@
+package game
+
+import (
+	"context"
+	"time"
+)
+
+// Outcome classifies the result a move leads to
+type Outcome int
+
+const (
+	OutcomeUnknown Outcome = iota // the search did not see the end of the game
+	OutcomeWin                    // the player to move wins by force
+	OutcomeLoss                   // the player to move loses by force
+	OutcomeDraw                   // the game ends in a draw with best play
+)
+
+// outcomeNames are the labels of the outcomes
+var outcomeNames = [...]string{"unknown", "win", "loss", "draw"}
+
+// String returns the outcome label
+func (o Outcome) String() string {
+	if int(o) < len(outcomeNames) {
+		return outcomeNames[o]
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the outcome as its label
+func (o Outcome) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+// ColumnAnalysis is the evaluation of one column for the player to move
+type ColumnAnalysis struct {
+	Col     int     `json:"col"`     // column, 0-indexed
+	Legal   bool    `json:"legal"`   // whether a disc can be dropped in the column
+	Score   int     `json:"score"`   // search score for the player to move, higher is better
+	Outcome Outcome `json:"outcome"` // forced result of the move, if the search proved one
+	Plies   int     `json:"plies"`   // plies until the forced result, this move included, 0 when unknown
+	PV      []int   `json:"pv"`      // principal variation starting with the column
+}
+
+// Analysis is the evaluation of every column of a position
+type Analysis struct {
+	ToMove  Cell                 `json:"to_move"` // player the scores are given for
+	Depth   int                  `json:"depth"`   // search depth in plies
+	Best    int                  `json:"best"`    // best column, -1 when the game is over
+	Columns [Cols]ColumnAnalysis `json:"columns"` // one entry per column, left to right
+}
+
+// Analyze searches every column of b for toMove to depth plies
+// an invalid toMove gets an empty analysis with no best column
+func Analyze(b *Board, toMove Cell, depth int) Analysis {
+	p, err := PositionFromBoard(b, toMove)
+	if err != nil {
+		return Analysis{ToMove: toMove, Depth: depth, Best: -1}
+	}
+	return AnalyzePosition(context.Background(), p, depth)
+}
+
+// AnalyzePosition searches every column of p to depth plies, stopping early when ctx is done
+func AnalyzePosition(ctx context.Context, p Position, depth int) Analysis {
+	depth = max(depth, 1)
+	a := Analysis{ToMove: p.Next, Depth: depth, Best: -1}
+	over := false
+	if _, ok := p.Winner(); ok || p.IsFull() {
+		over = true
+	}
+	for c := 0; c < Cols; c++ {
+		ca := &a.Columns[c]
+		ca.Col = c
+		if over || !p.CanPlay(c) {
+			continue
+		}
+		ca.Legal = true
+		ca.Score, ca.PV = scoreMove(ctx, p, c, depth, 0)
+		ca.Outcome, ca.Plies = classify(p, ca.Score, depth)
+		if a.Best < 0 || ca.Score > a.Columns[a.Best].Score {
+			a.Best = c
+		}
+	}
+	return a
+}
+
+// scoreMove returns the score of playing col in p for the player to move and the line expected after it,
+// searching the reply to depth-1 plies or for budget when positive
+func scoreMove(ctx context.Context, p Position, col, depth int, budget time.Duration) (int, []int) {
+	if p.IsWinningMove(col) {
+		return winScore, []int{col}
+	}
+	child := apply(p, col)
+	if child.IsFull() {
+		return 0, []int{col}
+	}
+	_, info := search(ctx, child, max(depth-1, 1), budget)
+	score := -info.Score
+	// a forced result one ply further away from p
+	if isMate(score) {
+		if score > 0 {
+			score--
+		} else {
+			score++
+		}
+	}
+	return score, append([]int{col}, info.PV...)
+}
+
+// classify turns a move score into a forced outcome and its distance in plies
+func classify(p Position, score, depth int) (Outcome, int) {
+	switch {
+	case isMate(score) && score > 0:
+		return OutcomeWin, winScore - score + 1
+	case isMate(score):
+		return OutcomeLoss, winScore + score + 1
+	case score == 0 && Rows*Cols-p.Moves <= depth:
+		// the search reached the full board on every line
+		return OutcomeDraw, Rows*Cols - p.Moves
+	}
+	return OutcomeUnknown, 0
+}
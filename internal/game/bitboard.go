@@ -0,0 +1,229 @@
+package game
+
+import "math/bits"
+
+// stride is the number of bits used per column: one per row plus a sentinel bit on top
+const stride = Rows + 1
+
+// Position is a bitboard view of a board used by the engines
+// bit (col*stride + h) is the cell at height h in col, h=0 being the bottom row
+type Position struct {
+	Stones [2]uint64   // discs of Player1 and Player2
+	Height [Cols]uint8 // number of discs in each column
+	Moves  int         // total number of discs on the board
+	Next   Cell        // player to move
+	Hash   uint64      // zobrist hash of the discs and the player to move
+	Misere bool        // completing a line loses, only the minimax engine honors it and the searches hand such positions to it
+}
+
+var (
+	bottomMask = genBottomMask()            // bottom cell of every column
+	boardMask  = bottomMask * (1<<Rows - 1) // every playable cell
+)
+
+// genBottomMask generates a mask with the bottom bit of each column set
+func genBottomMask() uint64 {
+	var m uint64
+	for c := 0; c < Cols; c++ {
+		m |= 1 << (c * stride)
+	}
+	return m
+}
+
+// colMask returns the playable cells of col
+func colMask(col int) uint64 {
+	return (1<<Rows - 1) << (col * stride)
+}
+
+// bottomCell returns the bottom cell of col
+func bottomCell(col int) uint64 {
+	return 1 << (col * stride)
+}
+
+// cellBit returns the bit of the grid cell at row r (0 is the top row) and column c
+func cellBit(r, c int) uint64 {
+	return 1 << (c*stride + Rows - 1 - r)
+}
+
+// NewPosition creates an empty position with p to move
+func NewPosition(p Cell) Position {
+	return Position{Next: p, Hash: sideHash(p)}
+}
+
+// PositionFromBoard builds the bitboard of b with next to move, b must use ClassicRules
+// it returns ErrInvalidPlayer when next is not Player1 or Player2
+func PositionFromBoard(b *Board, next Cell) (Position, error) {
+	if next != Player1 && next != Player2 {
+		return Position{}, ErrInvalidPlayer
+	}
+	pos := NewPosition(next)
+	for c := 0; c < Cols; c++ {
+		for r := Rows - 1; r >= 0; r-- {
+			v := b.Grid[r][c]
+			if v != Player1 && v != Player2 {
+				break
+			}
+			bit := cellBit(r, c)
+			pos.Stones[v-1] |= bit
+			pos.Hash ^= discHash(v, bit)
+			pos.Height[c]++
+			pos.Moves++
+		}
+	}
+	pos.Misere = b.Rules.Misere
+	return pos, nil
+}
+
+// ToBoard converts the position back to a grid board
+func (p *Position) ToBoard() Board {
+	b := NewBoard()
+	for c := 0; c < Cols; c++ {
+		for r := 0; r < Rows; r++ {
+			bit := cellBit(r, c)
+			if p.Stones[0]&bit != 0 {
+				b.set(r, c, Player1)
+			} else if p.Stones[1]&bit != 0 {
+				b.set(r, c, Player2)
+			}
+		}
+	}
+	b.Moves = p.Moves
+	return b
+}
+
+// mask returns every occupied cell
+func (p *Position) mask() uint64 {
+	return p.Stones[0] | p.Stones[1]
+}
+
+// current returns the discs of the player to move
+func (p *Position) current() uint64 {
+	return p.Stones[p.Next-1]
+}
+
+// CanPlay reports whether col is on the board and not full
+func (p *Position) CanPlay(col int) bool {
+	return col >= 0 && col < Cols && int(p.Height[col]) < Rows
+}
+
+// Play drops a disc for the player to move in col and passes the turn, col must be playable
+func (p *Position) Play(col int) {
+	p.playMove((p.mask() + bottomCell(col)) & colMask(col))
+}
+
+// playMove adds the single-bit move to the player to move and passes the turn
+func (p *Position) playMove(move uint64) {
+	p.Stones[p.Next-1] |= move
+	p.Height[bits.TrailingZeros64(move)/stride]++
+	p.Moves++
+	p.Hash ^= discHash(p.Next, move) ^ zobristSide
+	p.Next = opponent(p.Next)
+}
+
+// IsFull reports whether every cell is occupied
+func (p *Position) IsFull() bool {
+	return p.Moves >= Rows*Cols
+}
+
+// IsWinningMove reports whether playing col makes the player to move win
+func (p *Position) IsWinningMove(col int) bool {
+	return p.winningSpots()&p.possible()&colMask(col) != 0
+}
+
+// CanWinNext reports whether the player to move has an immediate win
+func (p *Position) CanWinNext() bool {
+	return p.winningSpots()&p.possible() != 0
+}
+
+// Winner returns the player owning a complete line, if any
+func (p *Position) Winner() (Cell, bool) {
+	if hasLine(p.Stones[0]) {
+		return Player1, true
+	}
+	if hasLine(p.Stones[1]) {
+		return Player2, true
+	}
+	return Empty, false
+}
+
+// Key returns a unique key for the position relative to the player to move
+func (p *Position) Key() uint64 {
+	return p.current() + p.mask()
+}
+
+// possible returns the cells where a disc can be dropped now
+func (p *Position) possible() uint64 {
+	return (p.mask() + bottomMask) & boardMask
+}
+
+// winningSpots returns the empty cells completing a line for the player to move
+func (p *Position) winningSpots() uint64 {
+	return lineSpots(p.current(), p.mask())
+}
+
+// opponentWinningSpots returns the empty cells completing a line for the opponent
+func (p *Position) opponentWinningSpots() uint64 {
+	return lineSpots(p.current()^p.mask(), p.mask())
+}
+
+// nonLosingMoves returns the playable cells that do not hand the opponent an immediate win
+// the player to move must not have an immediate win of their own
+func (p *Position) nonLosingMoves() uint64 {
+	possible := p.possible()
+	threats := p.opponentWinningSpots()
+	forced := possible & threats
+	if forced != 0 {
+		if forced&(forced-1) != 0 {
+			// two forced blocks at once cannot both be stopped
+			return 0
+		}
+		possible = forced
+	}
+	// never plays directly below an opponent winning spot
+	return possible &^ (threats >> 1)
+}
+
+// moveScore counts the winning spots created by the single-bit move
+func (p *Position) moveScore(move uint64) int {
+	return bits.OnesCount64(lineSpots(p.current()|move, p.mask()))
+}
+
+// directions lists the bit shifts of vertical, horizontal and both diagonal lines
+var directions = [4]uint{1, stride, stride - 1, stride + 1}
+
+// hasLine reports whether the stones contain toWin aligned discs
+func hasLine(stones uint64) bool {
+	for _, d := range directions {
+		m := stones
+		for i := uint(1); i < toWin; i++ {
+			m &= stones >> (i * d)
+		}
+		if m != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// lineSpots returns the empty cells that would complete a line for stones
+func lineSpots(stones, mask uint64) uint64 {
+	// vertical lines only grow upwards
+	r := stones << 1
+	for i := uint(2); i < toWin; i++ {
+		r &= stones << i
+	}
+
+	for _, d := range directions[1:] {
+		// lo[i] and hi[i] hold the cells having i aligned discs below and above them
+		var lo, hi [toWin]uint64
+		lo[0], hi[0] = ^uint64(0), ^uint64(0)
+		for i := uint(1); i < toWin; i++ {
+			lo[i] = lo[i-1] & (stones << (i * d))
+			hi[i] = hi[i-1] & (stones >> (i * d))
+		}
+		for a := 0; a < toWin; a++ {
+			r |= lo[a] & hi[toWin-1-a]
+		}
+	}
+	return r & (boardMask ^ mask)
+}
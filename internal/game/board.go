@@ -1,5 +1,6 @@
 package game
 
+// classic board dimensions, used by the bitboard engines and as the default rules
 const (
 	Cols  = 7
 	Rows  = 6
@@ -12,76 +13,197 @@ const (
 	Empty Cell = iota
 	Player1
 	Player2
+	Player3 // free-for-all rooms only
+	Player4 // free-for-all rooms only
+	Wall    // neutral blocker dropped as a power-up, part of no line
 )
 
+// Point is a grid cell, row 0 being the top row
+type Point struct {
+	Row int // grid row
+	Col int // grid column
+}
+
+// Line is a run of Rules.ToWin aligned cells held by one player
+type Line []Point
+
 type Board struct {
-	Grid  [Rows][Cols]Cell // board cells in row‑major order
-	Moves int              // total number of pieces placed so far
+	Rules Rules                  // board size and win length
+	Grid  [MaxRows][MaxCols]Cell // board cells in row‑major order, the top-left Rules.Rows x Rules.Cols are in play
+	Moves int                    // number of discs on the board
+
+	stones [2]uint64 // discs of Player1 and Player2 as bitboards, kept in step with Grid on classic-size boards
 }
 
-// NewBoard creates an empty board with zero moves
+// NewBoard creates an empty classic board with zero moves
 func NewBoard() Board {
-	return Board{}
+	return NewBoardWithRules(ClassicRules)
+}
+
+// NewBoardWithRules creates an empty board of the given size and win length
+func NewBoardWithRules(r Rules) Board {
+	return Board{Rules: r}
+}
+
+// bitboarded reports whether b keeps its discs in bitboards, which only the two-player rules on the classic board do
+func (b *Board) bitboarded() bool {
+	return b.Rules.IsClassic() || b.Rules.IsClassicMisere()
+}
+
+// set writes v to the cell at row r and column c, updating the bitboards of classic boards,
+// every write to Grid in the package going through it
+func (b *Board) set(r, c int, v Cell) {
+	if b.bitboarded() {
+		bit := cellBit(r, c)
+		b.stones[0] &^= bit
+		b.stones[1] &^= bit
+		if v == Player1 || v == Player2 {
+			b.stones[v-1] |= bit
+		}
+	}
+	b.Grid[r][c] = v
 }
 
 // IsFull returns whether the board has no remaining moves
 func IsFull(b *Board) bool {
-	return b.Moves >= Rows*Cols
+	return b.Moves >= b.Rules.Cells()
 }
 
-// IsGameWon checks horizontal, vertical, and diagonal lines and returns the winner if any
-func IsGameWon(board *Board) (Cell, bool) {
-	// checks horizontal sequences
-	for r := 0; r < Rows; r++ {
-		for c := 0; c <= Cols-toWin; c++ {
-			p := board.Grid[r][c]
-			if p != Empty &&
-				board.Grid[r][c+1] == p &&
-				board.Grid[r][c+2] == p &&
-				board.Grid[r][c+3] == p {
-				return p, true
+// CanMove reports whether who has a legal move: a drop in a column with room, or a pop of an own bottom disc under PopOut
+func CanMove(b *Board, who Cell) bool {
+	if !IsFull(b) {
+		return true
+	}
+	if b.Rules.PopOut {
+		for c := 0; c < b.Rules.Cols; c++ {
+			if b.Grid[b.Rules.Rows-1][c] == who {
+				return true
 			}
 		}
 	}
+	return false
+}
 
-	// checks vertical sequences
-	for c := 0; c < Cols; c++ {
-		for r := 0; r <= Rows-toWin; r++ {
-			p := board.Grid[r][c]
-			if p != Empty &&
-				board.Grid[r+1][c] == p &&
-				board.Grid[r+2][c] == p &&
-				board.Grid[r+3][c] == p {
-				return p, true
+// CanBeNext reports whether who can be the player to move on b, whichever player opened: one of the rules' players,
+// and with two players dropping discs only, the disc counts balanced or who one disc behind
+func CanBeNext(b *Board, who Cell) bool {
+	if who < Player1 || int(who) > b.Rules.PlayerCount() {
+		return false
+	}
+	// pops, power-ups and knocked out players leave the counts free
+	if b.Rules.PopOut || b.Rules.PowerUps > 0 || b.Rules.PlayerCount() != 2 {
+		return true
+	}
+	var n [3]int
+	for r := 0; r < b.Rules.Rows; r++ {
+		for c := 0; c < b.Rules.Cols; c++ {
+			if v := b.Grid[r][c]; v == Player1 || v == Player2 {
+				n[v]++
 			}
 		}
 	}
+	ahead := n[Player1] - n[Player2]
+	if who == Player2 {
+		ahead = -ahead
+	}
+	return ahead == 0 || ahead == -1
+}
+
+// IsGameWon checks horizontal, vertical, and diagonal lines and returns the winner if any
+func IsGameWon(board *Board) (Cell, bool) {
+	if board.bitboarded() {
+		switch {
+		case hasLine(board.stones[0]):
+			return Player1, true
+		case hasLine(board.stones[1]):
+			return Player2, true
+		}
+		return Empty, false
+	}
+	w, _, ok := IsGameWonLines(board)
+	return w, ok
+}
+
+// lineDirs are the row and column steps of the four line directions: horizontal, vertical and both diagonals
+var lineDirs = [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}}
 
-	// checks diagonal down‑right sequences
-	for r := 0; r <= Rows-toWin; r++ {
-		for c := 0; c <= Cols-toWin; c++ {
-			p := board.Grid[r][c]
-			if p != Empty &&
-				board.Grid[r+1][c+1] == p &&
-				board.Grid[r+2][c+2] == p &&
-				board.Grid[r+3][c+3] == p {
-				return p, true
+// IsGameWonLines checks every line like IsGameWon and also returns all the winning lines,
+// several when one disc completes more than one and overlapping ones for runs longer than the win length
+func IsGameWonLines(board *Board) (Cell, []Line, bool) {
+	rs := board.Rules
+	winner := Empty
+	var lines []Line
+	for r := 0; r < rs.Rows; r++ {
+		for c := 0; c < rs.Cols; c++ {
+			who := board.Grid[r][c]
+			if who == Empty || who == Wall {
+				continue
+			}
+			for _, d := range lineDirs {
+				endR, endC := r+d[0]*(rs.ToWin-1), c+d[1]*(rs.ToWin-1)
+				if endR < 0 || endR >= rs.Rows || endC < 0 || endC >= rs.Cols {
+					continue
+				}
+				l := make(Line, rs.ToWin)
+				ok := true
+				for i := 0; i < rs.ToWin && ok; i++ {
+					l[i] = Point{Row: r + d[0]*i, Col: c + d[1]*i}
+					ok = board.Grid[l[i].Row][l[i].Col] == who
+				}
+				if ok {
+					winner = who
+					lines = append(lines, l)
+				}
 			}
 		}
 	}
+	return winner, lines, len(lines) > 0
+}
 
-	// checks diagonal up‑right sequences
-	for r := toWin - 1; r < Rows; r++ {
-		for c := 0; c <= Cols-toWin; c++ {
-			p := board.Grid[r][c]
-			if p != Empty &&
-				board.Grid[r-1][c+1] == p &&
-				board.Grid[r-2][c+2] == p &&
-				board.Grid[r-3][c+3] == p {
-				return p, true
+// HasOpenLine reports whether who could still complete a line: some window of the win length holds only its discs and empty cells
+func HasOpenLine(board *Board, who Cell) bool {
+	rs := board.Rules
+	for r := 0; r < rs.Rows; r++ {
+		for c := 0; c < rs.Cols; c++ {
+			for _, d := range lineDirs {
+				endR, endC := r+d[0]*(rs.ToWin-1), c+d[1]*(rs.ToWin-1)
+				if endR < 0 || endR >= rs.Rows || endC < 0 || endC >= rs.Cols {
+					continue
+				}
+				open := true
+				for i := 0; i < rs.ToWin && open; i++ {
+					v := board.Grid[r+d[0]*i][c+d[1]*i]
+					open = v == Empty || v == who
+				}
+				if open {
+					return true
+				}
 			}
 		}
 	}
+	return false
+}
 
-	return Empty, false
+// WinsAt reports whether the disc at row r and column c completes a line
+func WinsAt(board *Board, r, c int) bool {
+	rs := board.Rules
+	who := board.Grid[r][c]
+	if who == Empty || who == Wall {
+		return false
+	}
+	for _, d := range lineDirs {
+		// counts the aligned discs on both sides of the cell
+		n := 1
+		for _, sign := range [2]int{1, -1} {
+			rr, cc := r+sign*d[0], c+sign*d[1]
+			for rr >= 0 && rr < rs.Rows && cc >= 0 && cc < rs.Cols && board.Grid[rr][cc] == who {
+				n++
+				rr, cc = rr+sign*d[0], cc+sign*d[1]
+			}
+		}
+		if n >= rs.ToWin {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,313 @@
+package game
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBadBook indicates a book line that cannot be read
+var ErrBadBook = errors.New("invalid book line")
+
+// bookSlack is the score difference under which book moves count as equally good
+// the static evaluation is too fine-grained for exact ties outside symmetric positions
+const bookSlack = 12
+
+// BookMove is a column and its search score for the player to move
+type BookMove struct {
+	Col   int // column played, 0-indexed
+	Score int // score for the player to move, in search units
+}
+
+// bookEntry is one book position stored in its canonical orientation
+type bookEntry struct {
+	pos   Position   // canonical position
+	moves []BookMove // every legal move, best first
+}
+
+// Book maps opening positions to scored moves
+// a position and its mirror image share one entry
+// the text format has one position per line: "<grid> <side> <col>:<score> ...", columns 1-indexed,
+// lines starting with '#' are comments
+type Book struct {
+	entries map[uint64]bookEntry // entries by canonical key
+}
+
+// NewBook creates an empty book
+func NewBook() *Book {
+	return &Book{entries: make(map[uint64]bookEntry)}
+}
+
+// Len returns the number of positions in the book
+func (b *Book) Len() int { return len(b.entries) }
+
+// mirrorKey returns a position key with the columns in reverse order
+func mirrorKey(k uint64) uint64 {
+	var m uint64
+	for c := 0; c < Cols; c++ {
+		col := (k >> (c * stride)) & (1<<stride - 1)
+		m |= col << ((Cols - 1 - c) * stride)
+	}
+	return m
+}
+
+// canonicalKey returns the smaller key of p and its mirror, and whether it is the mirror's
+func canonicalKey(p *Position) (uint64, bool) {
+	k := p.Key()
+	if m := mirrorKey(k); m < k {
+		return m, true
+	}
+	return k, false
+}
+
+// mirrorPosition returns p with the columns in reverse order
+func mirrorPosition(p *Position) Position {
+	b := p.ToBoard()
+	for r := 0; r < Rows; r++ {
+		for c := 0; c < Cols/2; c++ {
+			left, right := b.Grid[r][c], b.Grid[r][Cols-1-c]
+			b.set(r, c, right)
+			b.set(r, Cols-1-c, left)
+		}
+	}
+	m, _ := PositionFromBoard(&b, p.Next) // p.Next is always a player
+	return m
+}
+
+// Add stores the scored moves of p, replacing any previous entry for p or its mirror
+func (b *Book) Add(p Position, moves []BookMove) {
+	key, mirrored := canonicalKey(&p)
+	out := make([]BookMove, len(moves))
+	copy(out, moves)
+	if mirrored {
+		p = mirrorPosition(&p)
+		for i := range out {
+			out[i].Col = Cols - 1 - out[i].Col
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	b.entries[key] = bookEntry{pos: p, moves: out}
+}
+
+// Lookup returns the scored moves of p, best first
+func (b *Book) Lookup(p *Position) ([]BookMove, bool) {
+	key, mirrored := canonicalKey(p)
+	e, ok := b.entries[key]
+	if !ok {
+		return nil, false
+	}
+	out := make([]BookMove, len(e.moves))
+	copy(out, e.moves)
+	if mirrored {
+		for i := range out {
+			out[i].Col = Cols - 1 - out[i].Col
+		}
+	}
+	return out, true
+}
+
+// Pick returns the best book move for p, choosing at random among equally good moves when rng is not nil
+func (b *Book) Pick(p *Position, rng *rand.Rand) (int, bool) {
+	moves, ok := b.Lookup(p)
+	if !ok || len(moves) == 0 {
+		return -1, false
+	}
+	if rng == nil {
+		return moves[0].Col, true
+	}
+	n := 1
+	for n < len(moves) && moves[0].Score-moves[n].Score <= bookSlack {
+		n++
+	}
+	return moves[rng.Intn(n)].Col, true
+}
+
+// WriteTo writes the book in its text format, positions ordered by disc count then grid
+func (b *Book) WriteTo(w io.Writer) (int64, error) {
+	type line struct {
+		moves int
+		text  string
+	}
+	lines := make([]line, 0, len(b.entries))
+	for _, e := range b.entries {
+		board := e.pos.ToBoard()
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "%s %c", FormatGrid(&board), CellChar(e.pos.Next))
+		for _, m := range e.moves {
+			fmt.Fprintf(&sb, " %d:%d", m.Col+1, m.Score)
+		}
+		lines = append(lines, line{moves: e.pos.Moves, text: sb.String()})
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].moves != lines[j].moves {
+			return lines[i].moves < lines[j].moves
+		}
+		return lines[i].text < lines[j].text
+	})
+
+	bw := bufio.NewWriter(w)
+	var n int64
+	k, err := fmt.Fprintf(bw, "# power4 opening book, %d positions\n", len(lines))
+	n += int64(k)
+	if err != nil {
+		return n, err
+	}
+	for _, l := range lines {
+		k, err := fmt.Fprintln(bw, l.text)
+		n += int64(k)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// ReadBook reads a book in its text format
+func ReadBook(r io.Reader) (*Book, error) {
+	b := NewBook()
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		f := strings.Fields(text)
+		if len(f) < 3 || len(f[1]) != 1 {
+			return nil, fmt.Errorf("line %d: %w", line, ErrBadBook)
+		}
+		board, err := ParseGrid(f[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		side, ok := ParseCellChar(f[1][0])
+		if !ok {
+			return nil, fmt.Errorf("line %d: %w", line, ErrBadBook)
+		}
+		p, err := PositionFromBoard(&board, side)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, ErrBadBook)
+		}
+		moves := make([]BookMove, 0, len(f)-2)
+		for _, m := range f[2:] {
+			col, score, ok := strings.Cut(m, ":")
+			c, err1 := strconv.Atoi(col)
+			s, err2 := strconv.Atoi(score)
+			if !ok || err1 != nil || err2 != nil || !p.CanPlay(c-1) {
+				return nil, fmt.Errorf("line %d: %w", line, ErrBadBook)
+			}
+			moves = append(moves, BookMove{Col: c - 1, Score: s})
+		}
+		b.Add(p, moves)
+	}
+	return b, sc.Err()
+}
+
+// LoadBook reads the book file at path
+func LoadBook(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadBook(f)
+}
+
+// BuildBook scores every move of every position reachable in fewer than plies discs from start,
+// searching each move to depth plies or for budget, and calls progress after each position
+func BuildBook(ctx context.Context, start Position, plies, depth int, budget time.Duration, progress func(done int)) (*Book, error) {
+	b := NewBook()
+	level := []Position{start}
+	for ply := 0; ply < plies && len(level) > 0; ply++ {
+		seen := make(map[uint64]bool)
+		var next []Position
+		for _, p := range level {
+			if err := ctx.Err(); err != nil {
+				return b, err
+			}
+			if _, ok := p.Winner(); ok || p.IsFull() {
+				continue
+			}
+			var moves []BookMove
+			for c := 0; c < Cols; c++ {
+				if !p.CanPlay(c) {
+					continue
+				}
+				score, _ := scoreMove(ctx, p, c, depth, budget)
+				moves = append(moves, BookMove{Col: c, Score: score})
+
+				child := apply(p, c)
+				if k, _ := canonicalKey(&child); !seen[k] {
+					seen[k] = true
+					next = append(next, child)
+				}
+			}
+			b.Add(p, moves)
+			if progress != nil {
+				progress(b.Len())
+			}
+		}
+		level = next
+	}
+	return b, nil
+}
+
+var (
+	bookMu     sync.Mutex                                        // guards openingBook, bookRandom and bookRng
+	bookRandom bool                                              // whether bots pick at random among equally good book moves
+	bookRng    = rand.New(rand.NewSource(time.Now().UnixNano())) // draws the random book moves
+
+	// openingBook is the book consulted by the searching bots, nil for none
+	openingBook *Book
+)
+
+// SetOpeningBook makes the searching bots play from b when it knows the position, nil disables the book
+func SetOpeningBook(b *Book) {
+	bookMu.Lock()
+	defer bookMu.Unlock()
+	openingBook = b
+}
+
+// SetBookRandom sets whether bots vary between equally good book moves instead of always playing the first
+func SetBookRandom(on bool) {
+	bookMu.Lock()
+	defer bookMu.Unlock()
+	bookRandom = on
+}
+
+// bookMove returns the opening book move for p, if any
+func bookMove(p *Position) (int, bool) {
+	bookMu.Lock()
+	defer bookMu.Unlock()
+	if openingBook == nil {
+		return -1, false
+	}
+	var rng *rand.Rand
+	if bookRandom {
+		rng = bookRng
+	}
+	return openingBook.Pick(p, rng)
+}
+
+// BookBot plays opening book moves and lets Bot choose once the game leaves the book
+type BookBot struct {
+	Bot
+}
+
+// Move returns the book move when the position is in the opening book, otherwise asks the wrapped bot
+func (b BookBot) Move(ctx context.Context, p Position) (int, MoveInfo) {
+	if col, ok := bookMove(&p); ok {
+		return col, MoveInfo{PV: []int{col}}
+	}
+	return b.Bot.Move(ctx, p)
+}
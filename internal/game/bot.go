@@ -0,0 +1,179 @@
+package game
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MoveInfo describes how a bot chose its move
+type MoveInfo struct {
+	Score   int           // score for the bot, engine specific, 0 when unknown
+	Depth   int           // search depth reached in plies, 0 when unknown
+	Nodes   int64         // positions visited
+	PV      []int         // expected continuation starting with the chosen column
+	Elapsed time.Duration // wall-clock time spent on the move
+}
+
+// Bot chooses moves for the player to move in a position
+// implementations must be safe for concurrent use by several rooms
+type Bot interface {
+	// Name returns the display name of the bot
+	Name() string
+	// Move returns the chosen column, or -1 when no move is possible, and details about the choice
+	Move(ctx context.Context, p Position) (int, MoveInfo)
+}
+
+// levelBots maps the historical difficulty levels 1..6 to bot ids
+var levelBots = [...]string{"easy", "normal", "hard", "expert", "master", "perfect"}
+
+var (
+	botsMu sync.RWMutex // guards bots and botOrder
+
+	// bots holds the registered bots by id, starting with the engines shipped with the game
+	// the searching levels open from the opening book when one is loaded
+	bots = map[string]Bot{
+		"easy":    RandomBot{Label: "Easy"},
+		"normal":  GreedyBot{Label: "Normal"},
+		"hard":    BookBot{MinimaxBot{Label: "Hard", Depth: 3}},
+		"expert":  BookBot{MinimaxBot{Label: "Expert", Depth: 4}},
+		"master":  BookBot{SearchBot{Label: "Master", Budget: 2 * time.Second}},
+		"perfect": BookBot{PerfectBot{Label: "Perfect", Budget: 2 * time.Second}},
+		"mcts":    MCTSBot{Label: "Monte Carlo", Playouts: 20000, Exploration: math.Sqrt2},
+	}
+
+	// botOrder lists the ids in registration order
+	botOrder = append(append([]string(nil), levelBots[:]...), "mcts")
+)
+
+// RegisterBot makes b available under id, replacing any bot already registered with that id
+func RegisterBot(id string, b Bot) {
+	botsMu.Lock()
+	defer botsMu.Unlock()
+	if _, ok := bots[id]; !ok {
+		botOrder = append(botOrder, id)
+	}
+	bots[id] = b
+}
+
+// LookupBot returns the bot registered under id, or an EloBot for ids made by EloBotID
+func LookupBot(id string) (Bot, bool) {
+	botsMu.RLock()
+	defer botsMu.RUnlock()
+	if b, ok := bots[id]; ok {
+		return b, true
+	}
+	if elo, ok := parseEloBotID(id); ok {
+		return EloBot{Label: "≈" + strconv.Itoa(elo), Elo: elo}, true
+	}
+	return nil, false
+}
+
+// BotIDs returns the registered ids in registration order
+func BotIDs() []string {
+	botsMu.RLock()
+	defer botsMu.RUnlock()
+	return append([]string(nil), botOrder...)
+}
+
+// BotLevel returns the difficulty level of the bot registered under id, the level of the closest anchor for EloBot ids
+// and the highest level for other bots outside the levels
+func BotLevel(id string) int {
+	for i, lid := range levelBots {
+		if lid == id {
+			return i + 1
+		}
+	}
+	if elo, ok := parseEloBotID(id); ok {
+		return eloLevel(elo)
+	}
+	return len(levelBots)
+}
+
+// LevelBotID returns the id of the bot for a difficulty level, clamped to the known levels
+func LevelBotID(level int) string {
+	level = max(1, min(level, len(levelBots)))
+	return levelBots[level-1]
+}
+
+// RandomBot plays any legal column uniformly at random
+type RandomBot struct {
+	Label string // display name
+}
+
+// Name returns the display name
+func (b RandomBot) Name() string { return b.Label }
+
+// Move picks a random legal column
+func (b RandomBot) Move(_ context.Context, p Position) (int, MoveInfo) {
+	return pickRandom(&p), MoveInfo{}
+}
+
+// GreedyBot wins or blocks immediate threats and otherwise maximizes the static evaluation
+type GreedyBot struct {
+	Label string // display name
+}
+
+// Name returns the display name
+func (b GreedyBot) Name() string { return b.Label }
+
+// Move picks the best column one ply ahead
+func (b GreedyBot) Move(_ context.Context, p Position) (int, MoveInfo) {
+	return pickGreedy(&p), MoveInfo{Depth: 1}
+}
+
+// MinimaxBot searches a fixed number of plies with alpha‑beta minimax
+type MinimaxBot struct {
+	Label string // display name
+	Depth int    // search depth in plies
+}
+
+// Name returns the display name
+func (b MinimaxBot) Name() string { return b.Label }
+
+// Move picks the best column at the configured depth
+func (b MinimaxBot) Move(ctx context.Context, p Position) (int, MoveInfo) {
+	start := time.Now()
+	col := pickMinimax(ctx, &p, b.Depth)
+	return col, MoveInfo{Depth: b.Depth, Elapsed: time.Since(start)}
+}
+
+// SearchBot runs the iterative deepening search for a wall-clock budget, on several threads sharing one table
+type SearchBot struct {
+	Label   string        // display name
+	Budget  time.Duration // thinking time per move
+	Threads int           // threads searching together, zero for SearchThreads
+}
+
+// Name returns the display name
+func (b SearchBot) Name() string { return b.Label }
+
+// Move searches until the budget elapses or ctx is done
+func (b SearchBot) Move(ctx context.Context, p Position) (int, MoveInfo) {
+	threads := b.Threads
+	if threads <= 0 {
+		threads = SearchThreads()
+	}
+	return searchThreaded(ctx, p, Rows*Cols, b.Budget, threads)
+}
+
+// PerfectBot plays solver moves, searching for Budget on near-empty boards where solving is too slow
+type PerfectBot struct {
+	Label  string        // display name
+	Budget time.Duration // thinking time per opening move
+}
+
+// Name returns the display name
+func (b PerfectBot) Name() string { return b.Label }
+
+// Move returns a game-theoretically optimal column once the board is solvable in time
+func (b PerfectBot) Move(ctx context.Context, p Position) (int, MoveInfo) {
+	if p.Moves < solverMinMoves {
+		return searchThreaded(ctx, p, Rows*Cols, b.Budget, SearchThreads())
+	}
+	start := time.Now()
+	score, col := defaultSolver.Solve(p)
+	return col, MoveInfo{Score: score, Depth: Rows*Cols - p.Moves, Elapsed: time.Since(start)}
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eloBotPrefix starts the ids LookupBot resolves to an EloBot, followed by the target rating
+const eloBotPrefix = "elo:"
+
+// AnchorElo holds the fixed ratings of the level bots the Elo curve is calibrated against,
+// hard and expert only 50 points apart so the anchors pin down little above 1400
+var AnchorElo = map[string]int{
+	"easy":   400,
+	"normal": 1000,
+	"hard":   1400,
+	"expert": 1450,
+}
+
+// eloStep is a calibrated point of the Elo curve
+type eloStep struct {
+	Elo         int     // rating the settings play at
+	Depth       int     // search depth used to score the moves
+	Temperature float64 // score spread, in evaluation points, over which moves are picked by softmax
+	Blunder     float64 // chance of playing a random move other than the best
+}
+
+// eloCurve maps ratings to settings, the steps up to 1500 measured against the anchors with cmd/calibrate
+// the strongest step plays without randomness and tops the range: no anchor reaches it, so its 1650 is extrapolated
+// from the steps below rather than measured, deeper minimax gaining too little against the anchors to tell
+var eloCurve = []eloStep{
+	{Elo: 450, Depth: 1, Temperature: 300, Blunder: 0.6},
+	{Elo: 600, Depth: 1, Temperature: 100, Blunder: 0.3},
+	{Elo: 800, Depth: 2, Temperature: 100, Blunder: 0.2},
+	{Elo: 1000, Depth: 2, Temperature: 30, Blunder: 0.08},
+	{Elo: 1300, Depth: 4, Temperature: 10, Blunder: 0.02},
+	{Elo: 1500, Depth: 5, Temperature: 5, Blunder: 0.01},
+	{Elo: 1650, Depth: 7, Temperature: 0, Blunder: 0},
+}
+
+// EloBotRange returns the lowest and highest ratings an EloBot can target
+func EloBotRange() (int, int) { return eloCurve[0].Elo, eloCurve[len(eloCurve)-1].Elo }
+
+// eloSettings interpolates the curve at elo, clamped to its range; the depth is the one of the nearest step
+func eloSettings(elo int) eloStep {
+	lo, hi := EloBotRange()
+	elo = max(lo, min(elo, hi))
+	i := 0
+	for i < len(eloCurve)-2 && eloCurve[i+1].Elo <= elo {
+		i++
+	}
+	a, b := eloCurve[i], eloCurve[i+1]
+	t := float64(elo-a.Elo) / float64(b.Elo-a.Elo)
+	depth := a.Depth
+	if t >= 0.5 {
+		depth = b.Depth
+	}
+	return eloStep{
+		Elo:         elo,
+		Depth:       depth,
+		Temperature: a.Temperature + t*(b.Temperature-a.Temperature),
+		Blunder:     a.Blunder + t*(b.Blunder-a.Blunder),
+	}
+}
+
+// EloBotID returns the bot id of an EloBot targeting elo, clamped to EloBotRange
+func EloBotID(elo int) string {
+	lo, hi := EloBotRange()
+	return eloBotPrefix + strconv.Itoa(max(lo, min(elo, hi)))
+}
+
+// parseEloBotID returns the rating targeted by an EloBot id
+func parseEloBotID(id string) (int, bool) {
+	rest, ok := strings.CutPrefix(id, eloBotPrefix)
+	if !ok {
+		return 0, false
+	}
+	elo, err := strconv.Atoi(rest)
+	lo, hi := EloBotRange()
+	return elo, err == nil && elo >= lo && elo <= hi
+}
+
+// eloLevel returns the difficulty level whose anchor rating is closest to elo, for rules the EloBot cannot play,
+// the lower level winning a tie
+func eloLevel(elo int) int {
+	best, level := math.MaxInt, 1
+	// walks the levels from the lowest up so a tie keeps the lower one, ranging over the map would pick at random
+	for i, id := range levelBots {
+		a, ok := AnchorElo[id]
+		if !ok {
+			continue
+		}
+		if d := absInt(a - elo); d < best {
+			best, level = d, i+1
+		}
+	}
+	return level
+}
+
+// EloBot plays at a target rating: it scores every column with a shallow search, picks among them by softmax
+// and now and then blunders into a random move, both less often the higher the rating
+type EloBot struct {
+	Label string // display name
+	Elo   int    // target rating
+}
+
+// Name returns the display name
+func (b EloBot) Name() string { return b.Label }
+
+// Move picks a column the way a player of the target rating would
+func (b EloBot) Move(ctx context.Context, p Position) (int, MoveInfo) {
+	start := time.Now()
+	s := eloSettings(b.Elo)
+	col, score := eloPick(ctx, p, s)
+	return col, MoveInfo{Score: score, Depth: s.Depth, Elapsed: time.Since(start)}
+}
+
+// eloPick returns the column picked with the settings s and its score, -1 when p has no move
+func eloPick(ctx context.Context, p Position, s eloStep) (int, int) {
+	moves, scores := scoreRootMoves(ctx, &p, s.Depth)
+	if len(moves) == 0 {
+		return -1, 0
+	}
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	pick := best
+	if len(moves) > 1 && rand.Float64() < s.Blunder {
+		// a blunder is any other column, picked uniformly
+		pick = (best + 1 + rand.Intn(len(moves)-1)) % len(moves)
+	} else if s.Temperature > 0 {
+		// softmax over the scores, shifted by the best one so the weights stay finite
+		weights := make([]float64, len(moves))
+		total := 0.0
+		for i := range scores {
+			weights[i] = math.Exp(float64(scores[i]-scores[best]) / s.Temperature)
+			total += weights[i]
+		}
+		r := rand.Float64() * total
+		for pick = 0; pick < len(moves)-1 && r >= weights[pick]; pick++ {
+			r -= weights[pick]
+		}
+	}
+	return moves[pick], scores[pick]
+}
@@ -0,0 +1,339 @@
+package game
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrEngineTimeout indicates the engine did not answer in time
+	ErrEngineTimeout = errors.New("engine timeout")
+
+	// ErrEngineExited indicates the engine process closed its output
+	ErrEngineExited = errors.New("engine exited")
+
+	// ErrEngineBadMove indicates the engine answered an illegal or malformed move
+	ErrEngineBadMove = errors.New("engine returned an invalid move")
+)
+
+// engineGrace is the extra time an engine gets past its move time before it is restarted
+const engineGrace = 2 * time.Second
+
+// ExternalBot drives an engine running as a child process. Engines speak a line-based protocol on stdin/stdout.
+// Columns are 1-indexed, grids use the FormatGrid notation and sides are 'x' (Player1) or 'o' (Player2).
+//
+//	server -> engine                 engine -> server
+//	c4i                              id name <name>        (optional)
+//	                                 c4iok
+//	isready                          readyok
+//	newgame
+//	position <grid> <side>
+//	go movetime <ms> | go depth <n>  info depth <d> score <s> nodes <n> pv <col> <col> ...   (any number)
+//	                                 bestmove <col>
+//	stop                             bestmove <col>        (ends the current search early)
+//	quit
+//
+// isready follows the handshake and every newgame, which is sent before the first position of each game.
+// Unknown commands and lines must be ignored by both sides.
+type ExternalBot struct {
+	Label    string        // display name
+	Path     string        // executable path
+	Args     []string      // command-line arguments
+	MoveTime time.Duration // thinking time sent with each go command
+
+	mu    sync.Mutex     // serializes moves on the single process
+	cmd   *exec.Cmd      // running process, nil until started
+	in    io.WriteCloser // engine stdin
+	lines chan string    // engine stdout lines, closed when the process exits
+	last  *Position      // last position sent in the current game, nil until the first one
+}
+
+// NewExternalBot creates a bot for the engine at path, started on its first move
+func NewExternalBot(label, path string, args []string, moveTime time.Duration) *ExternalBot {
+	return &ExternalBot{Label: label, Path: path, Args: args, MoveTime: moveTime}
+}
+
+// Name returns the display name
+func (e *ExternalBot) Name() string { return e.Label }
+
+// Move asks the engine for a column, falling back to the greedy player when the engine fails
+func (e *ExternalBot) Move(ctx context.Context, p Position) (int, MoveInfo) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	start := time.Now()
+	col, info, err := e.think(ctx, p)
+	if err != nil {
+		log.Printf("engine %s: %v", e.Label, err)
+		e.kill()
+		col, info = pickGreedy(&p), MoveInfo{Depth: 1}
+	}
+	info.Elapsed = time.Since(start)
+	return col, info
+}
+
+// Close asks the engine to quit and releases the process
+func (e *ExternalBot) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.cmd == nil {
+		return nil
+	}
+	_ = e.send("quit")
+	e.kill()
+	return nil
+}
+
+// start launches the process and completes the handshake
+func (e *ExternalBot) start() error {
+	cmd := exec.Command(e.Path, e.Args...)
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// pumps stdout lines until the process exits
+	lines := make(chan string, 64)
+	go func() {
+		sc := bufio.NewScanner(out)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+	e.cmd, e.in, e.lines = cmd, in, lines
+
+	if err := e.send("c4i"); err != nil {
+		return err
+	}
+	if _, err := e.await(context.Background(), "c4iok", time.Now().Add(engineGrace), nil); err != nil {
+		return err
+	}
+	return e.ready()
+}
+
+// ready sends isready and waits for the engine to answer readyok
+func (e *ExternalBot) ready() error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	_, err := e.await(context.Background(), "readyok", time.Now().Add(engineGrace), nil)
+	return err
+}
+
+// follows reports whether p continues the game of the last position sent, every disc of it still in place
+func (e *ExternalBot) follows(p Position) bool {
+	l := e.last
+	return l != nil && p.Moves > l.Moves && p.Stones[0]&l.Stones[0] == l.Stones[0] && p.Stones[1]&l.Stones[1] == l.Stones[1]
+}
+
+// kill stops the process so the next move starts a fresh one
+func (e *ExternalBot) kill() {
+	if e.cmd == nil {
+		return
+	}
+	_ = e.in.Close()
+	if e.cmd.Process != nil {
+		_ = e.cmd.Process.Kill()
+	}
+	_ = e.cmd.Wait()
+
+	// lets the stdout pump finish even if nobody reads the remaining lines
+	go func(lines chan string) {
+		for range lines {
+		}
+	}(e.lines)
+	e.cmd, e.in, e.lines, e.last = nil, nil, nil, nil
+}
+
+// send writes one command line
+func (e *ExternalBot) send(line string) error {
+	_, err := io.WriteString(e.in, line+"\n")
+	return err
+}
+
+// drain discards lines left over from a previous exchange
+func (e *ExternalBot) drain() {
+	for {
+		select {
+		case _, ok := <-e.lines:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// await reads lines until one starts with prefix or the deadline passes, handing other lines to seen
+// when ctx is done the engine is asked to stop and gets a short grace period to answer
+func (e *ExternalBot) await(ctx context.Context, prefix string, deadline time.Time, seen func(string)) (string, error) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+	done := ctx.Done()
+	for {
+		select {
+		case <-done:
+			done = nil
+			if err := e.send("stop"); err != nil {
+				return "", err
+			}
+			timer.Reset(engineGrace)
+		case line, ok := <-e.lines:
+			if !ok {
+				return "", ErrEngineExited
+			}
+			line = strings.TrimSpace(line)
+			if line == prefix || strings.HasPrefix(line, prefix+" ") {
+				return line, nil
+			}
+			if seen != nil {
+				seen(line)
+			}
+		case <-timer.C:
+			return "", ErrEngineTimeout
+		}
+	}
+}
+
+// think sends the position and waits for the best move
+func (e *ExternalBot) think(ctx context.Context, p Position) (int, MoveInfo, error) {
+	var info MoveInfo
+	if e.cmd == nil {
+		if err := e.start(); err != nil {
+			return -1, info, err
+		}
+	}
+	e.drain()
+
+	// the bot is shared between games, so a position that does not follow on from the last one starts a new game
+	if !e.follows(p) {
+		if err := e.send("newgame"); err != nil {
+			return -1, info, err
+		}
+		if err := e.ready(); err != nil {
+			return -1, info, err
+		}
+	}
+	e.last = &p
+
+	b := p.ToBoard()
+	if err := e.send(fmt.Sprintf("position %s %c", FormatGrid(&b), CellChar(p.Next))); err != nil {
+		return -1, info, err
+	}
+	if err := e.send(fmt.Sprintf("go movetime %d", e.MoveTime.Milliseconds())); err != nil {
+		return -1, info, err
+	}
+
+	line, err := e.await(ctx, "bestmove", time.Now().Add(e.MoveTime+engineGrace), func(l string) {
+		if strings.HasPrefix(l, "info ") {
+			parseInfo(l, &info)
+		}
+	})
+	if err != nil {
+		return -1, info, err
+	}
+	f := strings.Fields(line)
+	if len(f) < 2 {
+		return -1, info, ErrEngineBadMove
+	}
+	n, err := strconv.Atoi(f[1])
+	if err != nil || !p.CanPlay(n-1) {
+		return -1, info, ErrEngineBadMove
+	}
+	return n - 1, info, nil
+}
+
+// parseInfo reads the depth, score, nodes and pv fields of an info line into info
+func parseInfo(line string, info *MoveInfo) {
+	f := strings.Fields(line)
+	for i := 1; i < len(f); i++ {
+		switch f[i] {
+		case "depth", "score", "nodes":
+			if i+1 >= len(f) {
+				return
+			}
+			n, err := strconv.ParseInt(f[i+1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch f[i] {
+			case "depth":
+				info.Depth = int(n)
+			case "score":
+				info.Score = int(n)
+			case "nodes":
+				info.Nodes = n
+			}
+			i++
+		case "pv":
+			info.PV = info.PV[:0]
+			for _, v := range f[i+1:] {
+				c, err := strconv.Atoi(v)
+				if err != nil {
+					break
+				}
+				info.PV = append(info.PV, c-1)
+			}
+			return
+		}
+	}
+}
+
+// engineConfig describes one external engine in the engines file
+type engineConfig struct {
+	ID         string   `json:"id"`          // registry id
+	Name       string   `json:"name"`        // display name
+	Path       string   `json:"path"`        // executable path
+	Args       []string `json:"args"`        // command-line arguments
+	MoveTimeMS int      `json:"movetime_ms"` // thinking time per move in milliseconds
+}
+
+// RegisterEngines registers every external engine listed in the JSON file at path, a missing file is not an error
+func RegisterEngines(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	var cfgs []engineConfig
+	if err := json.Unmarshal(data, &cfgs); err != nil {
+		return err
+	}
+	for _, c := range cfgs {
+		if c.ID == "" || c.Path == "" {
+			return fmt.Errorf("engine %q: id and path are required", c.Name)
+		}
+		name := c.Name
+		if name == "" {
+			name = c.ID
+		}
+		mt := time.Duration(c.MoveTimeMS) * time.Millisecond
+		if mt <= 0 {
+			mt = time.Second
+		}
+		RegisterBot(c.ID, NewExternalBot(name, c.Path, c.Args, mt))
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package game
 
-import "errors"
+import (
+	"errors"
+	"slices"
+	"time"
+)
 
 var (
 	// ErrColOutOfRange indicates the column index is out of range
@@ -14,23 +18,126 @@ var (
 
 	// ErrGameOver indicates the game is already over
 	ErrGameOver = errors.New("game over")
+
+	// ErrNoMoves indicates there is no move to undo
+	ErrNoMoves = errors.New("no moves to undo")
+
+	// ErrMoveMismatch indicates a recorded move that disagrees with the board it is replayed on
+	ErrMoveMismatch = errors.New("move does not match the board")
+
+	// ErrPopNotAllowed indicates a pop under rules without PopOut
+	ErrPopNotAllowed = errors.New("popping is not allowed")
+
+	// ErrCannotPop indicates a pop from a column whose bottom disc is not the player's
+	ErrCannotPop = errors.New("no own disc at the bottom of the column")
+
+	// ErrBadMoveKind indicates an unknown move kind
+	ErrBadMoveKind = errors.New("invalid move kind")
+
+	// ErrNoPowerUp indicates a special disc the player has none left of
+	ErrNoPowerUp = errors.New("no power-up of this kind left")
+
+	// ErrNoTarget indicates a bomb dropped on a column whose top disc is not an opponent's
+	ErrNoTarget = errors.New("no opponent disc to blow up")
+)
+
+// MoveKind tells how a move changes its column
+type MoveKind uint8
+
+const (
+	DropMove  MoveKind = iota // drops a disc on top of the column
+	PopMove                   // removes the player's disc from the bottom of the column, PopOut only
+	AnvilMove                 // crushes the column and lands on the bottom row as the player's disc, a power-up
+	WallMove                  // drops a neutral wall, a power-up
+	BombMove                  // blows up the opponent disc on top of the column, a power-up
 )
 
+// moveKindNames are the names of the move kinds used by forms
+var moveKindNames = [...]string{DropMove: "drop", PopMove: "pop", AnvilMove: "anvil", WallMove: "wall", BombMove: "bomb"}
+
+// String returns the name of k
+func (k MoveKind) String() string {
+	if int(k) < len(moveKindNames) {
+		return moveKindNames[k]
+	}
+	return "unknown"
+}
+
+// ParseMoveKind returns the move kind with the given name
+func ParseMoveKind(s string) (MoveKind, bool) {
+	for k, name := range moveKindNames {
+		if name == s {
+			return MoveKind(k), true
+		}
+	}
+	return DropMove, false
+}
+
+// Stock counts the special discs a player has left
+type Stock struct {
+	Anvils int // anvils left
+	Walls  int // walls left
+	Bombs  int // bombs left
+}
+
+// count returns the counter of the power-up kind k, nil for plain moves
+func (s *Stock) count(k MoveKind) *int {
+	switch k {
+	case AnvilMove:
+		return &s.Anvils
+	case WallMove:
+		return &s.Walls
+	case BombMove:
+		return &s.Bombs
+	}
+	return nil
+}
+
+// Has reports whether a move of kind k is in stock, plain moves always are
+func (s Stock) Has(k MoveKind) bool {
+	n := s.count(k)
+	return n == nil || *n > 0
+}
+
+// Move is one disc played in a game
+type Move struct {
+	Player  Cell      // player who dropped or popped the disc
+	Kind    MoveKind  // drop or pop
+	Col     int       // column played
+	Row     int       // row the disc landed on, the bottom row for pops and anvils, the blown up row for bombs
+	At      time.Time // when the move was played
+	Crushed []Cell    // discs crushed by an anvil bottom first, or the disc blown up by a bomb
+}
+
 type Game struct {
-	Board       Board  // current board state
-	NextPlayer  Cell   // player who plays next
-	Over        bool   // whether the game is over
-	Winner      Cell   // winner when Over is true, or Empty for draw
-	Player1Name string // display name for player 1
-	Player2Name string // display name for player 2
-	LastRow     int    // row of the last move, -1 if none
-	LastCol     int    // column of the last move, -1 if none
+	Board       Board                 // current board state
+	NextPlayer  Cell                  // player who plays next
+	Over        bool                  // whether the game is over
+	Winner      Cell                  // winner when Over is true, or Empty for draw
+	Player1Name string                // display name for player 1
+	Player2Name string                // display name for player 2
+	Player3Name string                // display name for player 3 in free-for-all games
+	Player4Name string                // display name for player 4 in free-for-all games
+	LastRow     int                   // row of the last move, -1 if none or if it was a pop
+	LastCol     int                   // column of the last move, -1 if none
+	WinLines    []Line                // lines completed by the winning move, nil unless won on the board
+	Reason      string                // how the game ended when the board alone does not tell, e.g. a repetition draw
+	Eliminated  []Cell                // players knocked out of a free-for-all game, in order
+	Stock       [MaxPlayers + 1]Stock // special discs left per player, indexed by Cell
+	Moves       []Move                // moves of the current game in order
+	Archive     [][]Move              // move lists of the previous games, oldest first
+	positions   []string              // PopOut only: the positions reached after each move, for the repetition rule
 }
 
-// NewGame creates a new game with an empty board and default names
+// NewGame creates a new classic game with an empty board and default names
 func NewGame() *Game {
-	return &Game{
-		Board:       NewBoard(),
+	return NewGameWithRules(ClassicRules)
+}
+
+// NewGameWithRules creates a new game on an empty board of the given size and win length
+func NewGameWithRules(r Rules) *Game {
+	g := &Game{
+		Board:       NewBoardWithRules(r),
 		NextPlayer:  Player1,
 		Winner:      Empty,
 		Over:        false,
@@ -39,52 +146,373 @@ func NewGame() *Game {
 		LastRow:     -1,
 		LastCol:     -1,
 	}
+	if r.PlayerCount() > 2 {
+		g.Player3Name = "Player 3"
+	}
+	if r.PlayerCount() > 3 {
+		g.Player4Name = "Player 4"
+	}
+	for p := Player1; int(p) <= r.PlayerCount(); p++ {
+		g.Stock[p] = Stock{Anvils: r.PowerUps, Walls: r.PowerUps, Bombs: r.PowerUps}
+	}
+	return g
+}
+
+// PlayerName returns the display name of player p
+func PlayerName(g *Game, p Cell) string {
+	switch p {
+	case Player1:
+		return g.Player1Name
+	case Player2:
+		return g.Player2Name
+	case Player3:
+		return g.Player3Name
+	case Player4:
+		return g.Player4Name
+	}
+	return ""
+}
+
+// SetPlayerName sets the display name of player p
+func SetPlayerName(g *Game, p Cell, name string) {
+	switch p {
+	case Player1:
+		g.Player1Name = name
+	case Player2:
+		g.Player2Name = name
+	case Player3:
+		g.Player3Name = name
+	case Player4:
+		g.Player4Name = name
+	}
 }
 
-// ToggleTurn switches the turn to the other player
+// NextSeat returns the player seated after p around a table of n players
+func NextSeat(p Cell, n int) Cell {
+	return Cell(int(p)%n + 1)
+}
+
+// PrevSeat returns the player seated before p around a table of n players
+func PrevSeat(p Cell, n int) Cell {
+	return Cell((int(p)+n-2)%n + 1)
+}
+
+// IsEliminated reports whether p was knocked out of the game
+func IsEliminated(g *Game, p Cell) bool {
+	for _, e := range g.Eliminated {
+		if e == p {
+			return true
+		}
+	}
+	return false
+}
+
+// nextPlayer returns the first player after who still in the game
+func nextPlayer(g *Game, who Cell) Cell {
+	n := g.Board.Rules.PlayerCount()
+	p := who
+	for i := 0; i < n; i++ {
+		p = NextSeat(p, n)
+		if !IsEliminated(g, p) {
+			return p
+		}
+	}
+	return who
+}
+
+// ToggleTurn passes the turn to the next player still in the game, rotating through every seat
 func ToggleTurn(g *Game) {
-	if g.NextPlayer == Player1 {
-		g.NextPlayer = Player2
-	} else {
-		g.NextPlayer = Player1
+	g.NextPlayer = nextPlayer(g, g.NextPlayer)
+}
+
+// Eliminate knocks p out of the game, their discs staying on the board, and ends it once a single player is left
+func Eliminate(g *Game, p Cell) error {
+	if g.Over {
+		return ErrGameOver
+	}
+	if p < Player1 || int(p) > g.Board.Rules.PlayerCount() || IsEliminated(g, p) {
+		return ErrInvalidPlayer
 	}
+	g.Eliminated = append(g.Eliminated, p)
+
+	// the last player standing wins
+	if last := nextPlayer(g, p); last == nextPlayer(g, last) {
+		g.Over, g.Winner = true, last
+		return nil
+	}
+	if g.NextPlayer == p {
+		ToggleTurn(g)
+	}
+	return nil
+}
+
+// Placings returns the finishing place of every player of an ended game indexed by Cell, 1 being the best:
+// the winner, then the players still in sharing a place, then the eliminated ones, the first out last
+func Placings(g *Game) []int {
+	n := g.Board.Rules.PlayerCount()
+	places := make([]int, n+1)
+	in := 1
+	if g.Winner != Empty {
+		places[g.Winner] = 1
+		in = 2
+	}
+	for p := Player1; int(p) <= n; p++ {
+		if p != g.Winner && !IsEliminated(g, p) {
+			places[p] = in
+		}
+	}
+	for i, p := range g.Eliminated {
+		places[p] = n - i
+	}
+	return places
 }
 
 // Play tries to apply a move in col, updates game state, and switches turn
 func Play(g *Game, col int) error {
+	return PlayMove(g, DropMove, col)
+}
+
+// PlayPop tries to pop the disc of the player to move from the bottom of col, under PopOut rules
+func PlayPop(g *Game, col int) error {
+	return PlayMove(g, PopMove, col)
+}
+
+// repetitionLimit is the number of occurrences of a PopOut position that draws the game
+const repetitionLimit = 3
+
+// PlayMove tries to apply a drop, a pop or a power-up in col, updates game state, and switches turn
+func PlayMove(g *Game, kind MoveKind, col int) error {
 	// rejects moves after game is over
 	if g.Over {
 		return ErrGameOver
 	}
 
-	// drops a piece and records the last move
-	row, err := AddPeon(&g.Board, col, g.NextPlayer)
+	// power-ups must be in stock
+	who := g.NextPlayer
+	if !g.Stock[who].Has(kind) {
+		return ErrNoPowerUp
+	}
+
+	// drops, pops or resolves the power-up and records the move
+	row := g.Board.Rules.Rows - 1
+	var crushed []Cell
+	var err error
+	switch kind {
+	case DropMove:
+		row, err = AddPeon(&g.Board, col, who)
+	case PopMove:
+		err = PopPeon(&g.Board, col, who)
+	case AnvilMove:
+		crushed, err = DropAnvil(&g.Board, col, who)
+	case WallMove:
+		row, err = DropWall(&g.Board, col)
+	case BombMove:
+		var hit Cell
+		row, hit, err = DropBomb(&g.Board, col, who)
+		crushed = []Cell{hit}
+	default:
+		err = ErrBadMoveKind
+	}
 	if err != nil {
 		return err
 	}
+	if n := g.Stock[who].count(kind); n != nil {
+		*n--
+	}
 	g.LastRow = row
 	g.LastCol = col
+	if kind == PopMove || kind == BombMove {
+		g.LastRow = -1
+	}
+	g.Moves = append(g.Moves, Move{Player: who, Kind: kind, Col: col, Row: row, At: time.Now(), Crushed: crushed})
+	next := nextPlayer(g, who)
+	key := ""
+	if g.Board.Rules.PopOut {
+		key = FormatGrid(&g.Board) + string(CellChar(next))
+		g.positions = append(g.positions, key)
+	}
 
-	// checks for a win
-	if w, ok := IsGameWon(&g.Board); ok {
-		g.Winner, g.Over = w, true
+	// checks for a win and keeps the lines that made it, under misère the lines lose
+	if w, lines := decidingLines(&g.Board, who); w != Empty {
+		if g.Board.Rules.Misere {
+			w = opponent(w)
+		}
+		g.Winner, g.Over, g.WinLines = w, true, lines
 		return nil
 	}
 
-	// checks for a draw
-	if IsFull(&g.Board) {
+	// checks for a draw, shared by the players still in, when the next player cannot move
+	if !CanMove(&g.Board, next) {
 		g.Over = true
 		return nil
 	}
 
+	// adjudicates a draw as soon as nobody can complete a line anymore instead of filling the board
+	if IsDeadPosition(g) {
+		g.Over, g.Reason = true, "Draw, no line can be completed anymore"
+		return nil
+	}
+
+	// PopOut games can cycle, the same position coming back too often is a draw
+	if key != "" {
+		seen := 0
+		for _, k := range g.positions {
+			if k == key {
+				seen++
+			}
+		}
+		if seen >= repetitionLimit {
+			g.Over, g.Reason = true, "Draw by threefold repetition"
+			return nil
+		}
+	}
+
 	// continues the game by switching turns
 	ToggleTurn(g)
 	return nil
 }
 
-// Reset resets the game while preserving player names
+// IsDeadPosition reports whether no player still in the game can complete a line whatever is played,
+// never under PopOut or while anvils or bombs are left, since they take discs off the board
+func IsDeadPosition(g *Game) bool {
+	if g.Board.Rules.PopOut {
+		return false
+	}
+	n := g.Board.Rules.PlayerCount()
+	for p := Player1; int(p) <= n; p++ {
+		if IsEliminated(g, p) {
+			continue
+		}
+		if g.Stock[p].Anvils > 0 || g.Stock[p].Bombs > 0 || HasOpenLine(&g.Board, p) {
+			return false
+		}
+	}
+	return true
+}
+
+// decidingLines returns the player who wins after who moved and the lines that make it, or Empty
+// a pop can complete lines for both players at once, the player who popped then wins
+func decidingLines(b *Board, who Cell) (Cell, []Line) {
+	_, lines, ok := IsGameWonLines(b)
+	if !ok {
+		return Empty, nil
+	}
+	var mine, theirs []Line
+	for _, l := range lines {
+		if b.Grid[l[0].Row][l[0].Col] == who {
+			mine = append(mine, l)
+		} else {
+			theirs = append(theirs, l)
+		}
+	}
+	if len(mine) > 0 {
+		return who, mine
+	}
+	return opponent(who), theirs
+}
+
+// Undo takes back the last move, reopening the game if it had ended
+func Undo(g *Game) error {
+	if len(g.Moves) == 0 {
+		return ErrNoMoves
+	}
+	m := g.Moves[len(g.Moves)-1]
+	g.Moves = g.Moves[:len(g.Moves)-1]
+
+	// lifts the disc, puts a popped, crushed or blown up one back, and gives the turn back to its player
+	switch m.Kind {
+	case PopMove:
+		unpopPeon(&g.Board, m.Col, m.Player)
+	case AnvilMove:
+		g.Board.set(m.Row, m.Col, Empty)
+		for i, c := range m.Crushed {
+			g.Board.set(g.Board.Rules.Rows-1-i, m.Col, c)
+		}
+		g.Board.Moves += len(m.Crushed) - 1
+	case BombMove:
+		g.Board.set(m.Row, m.Col, m.Crushed[0])
+		g.Board.Moves++
+	default:
+		g.Board.set(m.Row, m.Col, Empty)
+		g.Board.Moves--
+	}
+	if n := g.Stock[m.Player].count(m.Kind); n != nil {
+		*n++
+	}
+	if n := len(g.positions); n > 0 {
+		g.positions = g.positions[:n-1]
+	}
+	g.NextPlayer = m.Player
+	g.Over, g.Winner, g.WinLines, g.Reason = false, Empty, nil, ""
+
+	// points the last move at the previous one
+	g.LastRow, g.LastCol = -1, -1
+	if n := len(g.Moves); n > 0 {
+		g.LastCol = g.Moves[n-1].Col
+		if k := g.Moves[n-1].Kind; k != PopMove && k != BombMove {
+			g.LastRow = g.Moves[n-1].Row
+		}
+	}
+	return nil
+}
+
+// Replay creates a classic game by playing moves in order from an empty board, the first move deciding who starts
+// each move must come from the player to move and land on its recorded row, failures are *PlyError values
+func Replay(moves []Move) (*Game, error) {
+	g := NewGame()
+	if len(moves) > 0 {
+		g.NextPlayer = moves[0].Player
+		if g.NextPlayer != Player1 && g.NextPlayer != Player2 {
+			return nil, &PlyError{Ply: 1, Err: ErrInvalidPlayer}
+		}
+	}
+	for i, m := range moves {
+		if m.Player != g.NextPlayer {
+			return nil, &PlyError{Ply: i + 1, Err: ErrMoveMismatch}
+		}
+		if err := PlayMove(g, m.Kind, m.Col); err != nil {
+			return nil, &PlyError{Ply: i + 1, Err: err}
+		}
+		if g.Moves[i].Row != m.Row {
+			return nil, &PlyError{Ply: i + 1, Err: ErrMoveMismatch}
+		}
+		// keeps the recorded time instead of the replay time
+		g.Moves[i].At = m.At
+	}
+	return g, nil
+}
+
+// Reset starts a new game while preserving player names and rules and archiving the moves of the previous one
 func Reset(g *Game) {
-	p1, p2 := g.Player1Name, g.Player2Name
-	*g = *NewGame()
-	g.Player1Name, g.Player2Name = p1, p2
+	p1, p2, p3, p4 := g.Player1Name, g.Player2Name, g.Player3Name, g.Player4Name
+	archive := g.Archive
+	if len(g.Moves) > 0 {
+		archive = append(archive, g.Moves)
+	}
+	*g = *NewGameWithRules(g.Board.Rules)
+	g.Player1Name, g.Player2Name, g.Player3Name, g.Player4Name = p1, p2, p3, p4
+	g.Archive = archive
+}
+
+// ResetTo starts a new game from a copy of start, keeping the names and archiving the moves played
+func ResetTo(g, start *Game) {
+	p1, p2, p3, p4 := g.Player1Name, g.Player2Name, g.Player3Name, g.Player4Name
+	archive := g.Archive
+	if len(g.Moves) > 0 {
+		archive = append(archive, g.Moves)
+	}
+	*g = *Clone(start)
+	g.Player1Name, g.Player2Name, g.Player3Name, g.Player4Name = p1, p2, p3, p4
+	g.Archive = archive
+}
+
+// Clone returns a copy of g that can be played on without changing g
+func Clone(g *Game) *Game {
+	c := *g
+	c.WinLines = slices.Clone(g.WinLines)
+	c.Eliminated = slices.Clone(g.Eliminated)
+	c.Moves = slices.Clone(g.Moves)
+	c.Archive = slices.Clone(g.Archive)
+	c.positions = slices.Clone(g.positions)
+	return &c
 }
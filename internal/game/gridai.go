@@ -0,0 +1,236 @@
+package game
+
+import (
+	"math"
+	"sort"
+)
+
+// gridMaxDepth caps the search depth of the grid engine, whose boards can be wider than the classic one
+const gridMaxDepth = 5
+
+// powerUpCost is taken from the score of playing a power-up so the engine saves them for when they matter
+const powerUpCost = 40
+
+// gridEval generates a heuristic score for me like eval, on a board of any rules
+func gridEval(b *Board, me Cell) int {
+	rs := b.Rules
+	opp := opponent(me)
+	wt := evalWeights.Load()
+
+	// favors center column occupancy, which joins the most lines
+	score := 0
+	for r := 0; r < rs.Rows; r++ {
+		if b.Grid[r][rs.Cols/2] == me {
+			score += wt.Center
+		}
+	}
+	if rs.Misere {
+		score = -score
+	}
+
+	// scores every horizontal, vertical and diagonal window
+	for r := 0; r < rs.Rows; r++ {
+		for c := 0; c < rs.Cols; c++ {
+			for _, d := range lineDirs {
+				er, ec := r+d[0]*(rs.ToWin-1), c+d[1]*(rs.ToWin-1)
+				if er < 0 || er >= rs.Rows || ec < 0 || ec >= rs.Cols {
+					continue
+				}
+				mine, theirs := 0, 0
+				for i := 0; i < rs.ToWin; i++ {
+					switch b.Grid[r+d[0]*i][c+d[1]*i] {
+					case me:
+						mine++
+					case opp:
+						theirs++
+					}
+				}
+				score += countWindow(wt, mine, theirs, rs.ToWin, rs.Misere)
+			}
+		}
+	}
+	return score
+}
+
+// gridAction is a drop or a pop the grid engine considers
+type gridAction struct {
+	kind MoveKind // drop or pop
+	col  int      // column played
+}
+
+// gridActions returns the moves of who on b, drops center first then pops when allowed
+func gridActions(b *Board, who Cell, pops bool) []gridAction {
+	center := b.Rules.Cols / 2
+	out := make([]gridAction, 0, 2*b.Rules.Cols)
+	for c := 0; c < b.Rules.Cols; c++ {
+		if b.Grid[0][c] == Empty {
+			out = append(out, gridAction{kind: DropMove, col: c})
+		}
+	}
+	if pops && b.Rules.PopOut {
+		for c := 0; c < b.Rules.Cols; c++ {
+			if b.Grid[b.Rules.Rows-1][c] == who {
+				out = append(out, gridAction{kind: PopMove, col: c})
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].kind != out[j].kind {
+			return out[i].kind < out[j].kind
+		}
+		return absInt(out[i].col-center) < absInt(out[j].col-center)
+	})
+	return out
+}
+
+// powerActions returns the power-ups who can play on b with the given stock, tried at the root of the search only
+func powerActions(b *Board, who Cell, stock Stock) []gridAction {
+	var out []gridAction
+	for c := 0; c < b.Rules.Cols; c++ {
+		top := Empty
+		for r := 0; r < b.Rules.Rows && top == Empty; r++ {
+			top = b.Grid[r][c]
+		}
+		// anvils need discs to crush, bombs an opponent disc on top
+		if stock.Anvils > 0 && top != Empty {
+			out = append(out, gridAction{kind: AnvilMove, col: c})
+		}
+		if stock.Walls > 0 && b.Grid[0][c] == Empty {
+			out = append(out, gridAction{kind: WallMove, col: c})
+		}
+		if stock.Bombs > 0 && top != Empty && top != who && top != Wall {
+			out = append(out, gridAction{kind: BombMove, col: c})
+		}
+	}
+	return out
+}
+
+// apply plays a on b for who and returns the row a dropped disc landed on
+// power-ups are not undone, the search plays them on a copy of the board
+func (a gridAction) apply(b *Board, who Cell) int {
+	switch a.kind {
+	case PopMove:
+		_ = PopPeon(b, a.col, who)
+		return b.Rules.Rows - 1
+	case AnvilMove:
+		_, _ = DropAnvil(b, a.col, who)
+		return b.Rules.Rows - 1
+	case WallMove:
+		row, _ := DropWall(b, a.col)
+		return row
+	case BombMove:
+		row, _, _ := DropBomb(b, a.col, who)
+		return row
+	}
+	row, _ := AddPeon(b, a.col, who)
+	return row
+}
+
+// undo takes a drop or a pop back from b, row being the value apply returned
+func (a gridAction) undo(b *Board, who Cell, row int) {
+	if a.kind == PopMove {
+		unpopPeon(b, a.col, who)
+		return
+	}
+	b.set(row, a.col, Empty)
+	b.Moves--
+}
+
+// winner returns the player a decides the game for, who having just played it at row, or Empty
+func (a gridAction) winner(b *Board, who Cell, row int) Cell {
+	// a drop can only complete lines through its own disc, a pop shifts a whole column
+	w := Empty
+	if a.kind == DropMove {
+		if WinsAt(b, row, a.col) {
+			w = who
+		}
+	} else {
+		w, _ = decidingLines(b, who)
+	}
+	if w != Empty && b.Rules.Misere {
+		w = opponent(w)
+	}
+	return w
+}
+
+// gridMinimax explores moves on b with alpha‑beta pruning, pops included when pops is set
+func gridMinimax(b *Board, depth, alpha, beta int, maximizing bool, me Cell, pops bool) int {
+	if depth == 0 {
+		return gridEval(b, me)
+	}
+
+	who := me
+	if !maximizing {
+		who = opponent(me)
+	}
+	actions := gridActions(b, who, pops)
+	if len(actions) == 0 {
+		return 0
+	}
+	best := math.MinInt32
+	if !maximizing {
+		best = math.MaxInt32
+	}
+	for _, a := range actions {
+		row := a.apply(b, who)
+		// scores finished games, sooner wins and later losses first
+		var e int
+		switch a.winner(b, who, row) {
+		case me:
+			e = 100000 + depth
+		case opponent(me):
+			e = -100000 - depth
+		default:
+			e = gridMinimax(b, depth-1, alpha, beta, !maximizing, me, pops)
+		}
+		a.undo(b, who, row)
+		if maximizing {
+			best = max(best, e)
+			alpha = max(alpha, best)
+		} else {
+			best = min(best, e)
+			beta = min(beta, best)
+		}
+		if beta <= alpha {
+			break
+		}
+	}
+	return best
+}
+
+// pickGridAction generates a move for who on a board of any rules using minimax at the requested depth
+// the power-ups in stock compete with the plain moves at the root, deeper plies only drop and pop
+func pickGridAction(b *Board, who Cell, depth int, pops bool, stock Stock) (gridAction, bool) {
+	var best gridAction
+	found := false
+	bestScore := math.MinInt32
+	for _, a := range append(gridActions(b, who, pops), powerActions(b, who, stock)...) {
+		work := *b
+		row := a.apply(&work, who)
+		var score int
+		switch a.winner(&work, who, row) {
+		case who:
+			score = 100000 + depth
+		case opponent(who):
+			score = -100000 - depth
+		default:
+			score = gridMinimax(&work, depth-1, math.MinInt32/2, math.MaxInt32/2, false, who, pops)
+		}
+		if a.kind != DropMove && a.kind != PopMove {
+			score -= powerUpCost
+		}
+		if !found || score > bestScore {
+			bestScore, best, found = score, a, true
+		}
+	}
+	return best, found
+}
+
+// pickGridMinimax generates a drop for who on a board of any rules using minimax at the requested depth
+func pickGridMinimax(b *Board, who Cell, depth int) int {
+	a, ok := pickGridAction(b, who, depth, false, Stock{})
+	if !ok {
+		return -1
+	}
+	return a.col
+}
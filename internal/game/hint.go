@@ -0,0 +1,124 @@
+package game
+
+import (
+	"context"
+	"fmt"
+)
+
+// hintDepth is how many plies hints search the classic board, other rules asking the strongest grid bot
+const hintDepth = 10
+
+// Hint is the move the engine suggests to the player to move, with a short reason
+type Hint struct {
+	Kind   MoveKind // drop, pop or power-up to play
+	Col    int      // column to play, 0-indexed
+	Reason string   // why the move helps, e.g. "blocks a three"
+}
+
+// SuggestMove asks the engine for the best move of who on b and explains it, ok being false when who has no move
+func SuggestMove(ctx context.Context, b *Board, who Cell, stock Stock) (Hint, bool) {
+	h := Hint{Kind: DropMove, Col: -1}
+	var a *Analysis
+	if b.Rules.IsClassic() {
+		p, err := PositionFromBoard(b, who)
+		if err != nil {
+			return h, false
+		}
+		an := AnalyzePosition(ctx, p, hintDepth)
+		h.Col, a = an.Best, &an
+	} else {
+		h.Kind, h.Col = ComputeBotAction(b, who, stock, len(levelBots))
+	}
+	if h.Col < 0 {
+		return h, false
+	}
+	h.Reason = hintReason(b, who, h, a)
+	return h, true
+}
+
+// hintReason explains the move h of who on b by what it does on the board,
+// the analysis a of a classic position adding the forced results it proved
+func hintReason(b *Board, who Cell, h Hint, a *Analysis) string {
+	switch h.Kind {
+	case PopMove:
+		return "pops your disc to reshape the column"
+	case AnvilMove:
+		return "crushes the column with an anvil"
+	case WallMove:
+		return "walls off the column"
+	case BombMove:
+		return "blows up the opponent's top disc"
+	}
+
+	after := *b
+	r, err := AddPeon(&after, h.Col, who)
+	if err != nil {
+		return ""
+	}
+	// completing a line loses under misère, so lines only explain moves in normal play
+	if !b.Rules.Misere {
+		if WinsAt(&after, r, h.Col) {
+			return "wins the game"
+		}
+		blocked := *b
+		blocked.set(r, h.Col, opponent(who))
+		if WinsAt(&blocked, r, h.Col) {
+			return "blocks a " + lineName(b.Rules.ToWin-1)
+		}
+		switch winningDrops(&after, who) {
+		case 0:
+		case 1:
+			return "threatens to complete a line"
+		default:
+			return "creates a double threat"
+		}
+	}
+
+	if a != nil {
+		c := a.Columns[h.Col]
+		switch {
+		case c.Outcome == OutcomeWin:
+			return fmt.Sprintf("wins by force in %d moves", (c.Plies+1)/2)
+		case c.Outcome == OutcomeLoss:
+			return "holds out the longest"
+		case savingMoves(a) == 1:
+			return "is the only move that does not lose"
+		}
+	}
+	if h.Col == b.Rules.Cols/2 {
+		return "takes the center"
+	}
+	return "keeps the best position"
+}
+
+// winningDrops counts the columns where a drop by who completes a line right away
+func winningDrops(b *Board, who Cell) int {
+	n := 0
+	for c := 0; c < b.Rules.Cols; c++ {
+		next := *b
+		if r, err := AddPeon(&next, c, who); err == nil && WinsAt(&next, r, c) {
+			n++
+		}
+	}
+	return n
+}
+
+// savingMoves counts the legal columns of a that are not proven losses
+func savingMoves(a *Analysis) int {
+	n := 0
+	for _, c := range a.Columns {
+		if c.Legal && c.Outcome != OutcomeLoss {
+			n++
+		}
+	}
+	return n
+}
+
+// lineName names a line of n discs the way players do
+func lineName(n int) string {
+	names := [...]string{2: "two", 3: "three", 4: "four", 5: "five", 6: "six"}
+	if n >= 2 && n < len(names) {
+		return names[n]
+	}
+	return fmt.Sprintf("line of %d", n)
+}
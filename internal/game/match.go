@@ -0,0 +1,261 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+)
+
+// ErrIllegalMove indicates a bot answered with a column it cannot play
+var ErrIllegalMove = errors.New("illegal move")
+
+// MovePicker chooses the column who plays on b, -1 when it has none
+type MovePicker func(b *Board, who Cell) int
+
+// LevelPicker plays ComputeBotMove at the given difficulty level
+func LevelPicker(level int) MovePicker {
+	return func(b *Board, who Cell) int { return ComputeBotMove(b, who, level) }
+}
+
+// BotPicker plays the classic board with bot
+func BotPicker(bot Bot) MovePicker {
+	return func(b *Board, who Cell) int {
+		p, err := PositionFromBoard(b, who)
+		if err != nil {
+			return -1
+		}
+		col, _ := bot.Move(context.Background(), p)
+		return col
+	}
+}
+
+// MatchResult counts the games of a match from the point of view of the first engine
+type MatchResult struct {
+	Wins   int // games the first engine won
+	Draws  int // drawn games
+	Losses int // games the first engine lost
+}
+
+// Games returns the number of games played
+func (r MatchResult) Games() int { return r.Wins + r.Draws + r.Losses }
+
+// Score returns the share of points the first engine scored, 0.5 before any game
+func (r MatchResult) Score() float64 {
+	if r.Games() == 0 {
+		return 0.5
+	}
+	return (float64(r.Wins) + float64(r.Draws)/2) / float64(r.Games())
+}
+
+// variance returns the variance of the points of one game
+func (r MatchResult) variance() float64 {
+	n, s := float64(r.Games()), r.Score()
+	return (float64(r.Wins)*(1-s)*(1-s) + float64(r.Draws)*(0.5-s)*(0.5-s) + float64(r.Losses)*s*s) / n
+}
+
+// eloOf converts a score share into an Elo difference, clamped away from 0 and 1
+func eloOf(s float64) float64 {
+	s = min(max(s, 1e-3), 1-1e-3)
+	return -400 * math.Log10(1/s-1)
+}
+
+// scoreOf converts an Elo difference into the expected score share
+func scoreOf(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Elo returns the Elo difference of the first engine and the half width of its 95% confidence interval
+func (r MatchResult) Elo() (float64, float64) {
+	if r.Games() == 0 {
+		return 0, math.Inf(1)
+	}
+	s := r.Score()
+	se := math.Sqrt(r.variance() / float64(r.Games()))
+	lo, hi := eloOf(s-1.96*se), eloOf(s+1.96*se)
+	return eloOf(s), (hi - lo) / 2
+}
+
+// LLR returns the log-likelihood ratio of elo1 against elo0 for the first engine, with the normal approximation of the game results
+func (r MatchResult) LLR(elo0, elo1 float64) float64 {
+	v := r.variance()
+	if r.Games() == 0 || v == 0 {
+		return 0
+	}
+	s0, s1 := scoreOf(elo0), scoreOf(elo1)
+	return float64(r.Games()) * (s1 - s0) * (2*r.Score() - s0 - s1) / (2 * v)
+}
+
+// SPRT returns +1 once the results accept elo1, -1 once they accept elo0 and 0 while more games are needed,
+// alpha and beta being the false positive and false negative rates
+func (r MatchResult) SPRT(elo0, elo1, alpha, beta float64) int {
+	llr := r.LLR(elo0, elo1)
+	lower, upper := SPRTBounds(alpha, beta)
+	switch {
+	case llr >= upper:
+		return 1
+	case llr <= lower:
+		return -1
+	}
+	return 0
+}
+
+// SPRTBounds returns the lower and upper LLR bounds of SPRT
+func SPRTBounds(alpha, beta float64) (float64, float64) {
+	return math.Log(beta / (1 - alpha)), math.Log((1 - beta) / alpha)
+}
+
+// PlayMatchGame plays a classic game from the opening columns between a, seated as aSeat, and b,
+// and returns the points of a: 1, 0.5 or 0; a bot answering with an illegal column loses
+func PlayMatchGame(a, b MovePicker, opening []int, aSeat Cell) (float64, error) {
+	g := NewGame()
+	for _, c := range opening {
+		if err := Play(g, c); err != nil {
+			return 0, err
+		}
+	}
+	for !g.Over {
+		who := g.NextPlayer
+		pick := b
+		if who == aSeat {
+			pick = a
+		}
+		if err := Play(g, pick(&g.Board, who)); err != nil {
+			if who == aSeat {
+				return 0, ErrIllegalMove
+			}
+			return 1, ErrIllegalMove
+		}
+	}
+	switch g.Winner {
+	case Empty:
+		return 0.5, nil
+	case aSeat:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// PlayMatch plays games between a and b on workers goroutines, each opening twice with the seats swapped,
+// cycling through the openings, or from the empty board when there are none;
+// after every game it calls done with the results so far, and stops starting games once done returns true or ctx is done
+func PlayMatch(ctx context.Context, a, b MovePicker, openings [][]int, games, workers int, done func(MatchResult) bool) MatchResult {
+	if len(openings) == 0 {
+		openings = [][]int{nil}
+	}
+	var (
+		mu     sync.Mutex
+		res    MatchResult
+		next   int
+		finish bool
+	)
+	var wg sync.WaitGroup
+	for w := 0; w < max(workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if finish || next >= games || ctx.Err() != nil {
+					mu.Unlock()
+					return
+				}
+				i := next
+				next++
+				mu.Unlock()
+
+				// the first engine plays Player1 in even games and Player2 in odd ones
+				seat := Player1
+				if i%2 == 1 {
+					seat = Player2
+				}
+				pts, _ := PlayMatchGame(a, b, openings[(i/2)%len(openings)], seat)
+
+				mu.Lock()
+				switch pts {
+				case 1:
+					res.Wins++
+				case 0:
+					res.Losses++
+				default:
+					res.Draws++
+				}
+				if done != nil && done(res) {
+					finish = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return res
+}
+
+// BalancedOpenings returns the openings of the given number of plies, mirror images once, where the player to move
+// has no forced result within depth plies and the best score stays within margin
+func BalancedOpenings(ctx context.Context, plies, depth, margin int) [][]int {
+	var out [][]int
+	seen := make(map[uint64]bool)
+	var walk func(p Position, line []int)
+	walk = func(p Position, line []int) {
+		if ctx.Err() != nil {
+			return
+		}
+		if len(line) == plies {
+			key, _ := canonicalKey(&p)
+			if seen[key] {
+				return
+			}
+			seen[key] = true
+			a := AnalyzePosition(ctx, p, depth)
+			if a.Best < 0 {
+				return
+			}
+			best := a.Columns[a.Best]
+			if best.Outcome == OutcomeUnknown && absInt(best.Score) <= margin {
+				out = append(out, append([]int(nil), line...))
+			}
+			return
+		}
+		for c := 0; c < Cols; c++ {
+			if !p.CanPlay(c) || p.IsWinningMove(c) {
+				continue
+			}
+			child := p
+			child.Play(c)
+			walk(child, append(line, c))
+		}
+	}
+	walk(NewPosition(Player1), nil)
+	return out
+}
+
+// PerformanceRating returns the rating whose expected score against opponents rated ratings matches the results,
+// found by bisection within 1000 points of the opponents
+func PerformanceRating(ratings []int, results []MatchResult) float64 {
+	if len(ratings) == 0 {
+		return 0
+	}
+	lo, hi := float64(ratings[0]), float64(ratings[0])
+	for _, r := range ratings {
+		lo, hi = min(lo, float64(r)), max(hi, float64(r))
+	}
+	lo, hi = lo-1000, hi+1000
+	// surplus is the points scored above the expectation of a player rated x, decreasing in x
+	surplus := func(x float64) float64 {
+		s := 0.0
+		for i, r := range results {
+			s += float64(r.Wins) + float64(r.Draws)/2 - float64(r.Games())*scoreOf(x-float64(ratings[i]))
+		}
+		return s
+	}
+	for i := 0; i < 60; i++ {
+		mid := (lo + hi) / 2
+		if surplus(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
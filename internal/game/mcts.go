@@ -0,0 +1,179 @@
+package game
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// MCTSBot runs a Monte Carlo tree search with UCT selection
+type MCTSBot struct {
+	Label       string  // display name
+	Playouts    int     // simulated games per move
+	Exploration float64 // UCT exploration constant, higher values favor less visited moves
+	Seed        int64   // random seed making play deterministic, 0 seeds from the clock
+}
+
+// mctsNode is one position of the search tree
+type mctsNode struct {
+	parent   *mctsNode   // node this move was played from
+	col      int         // column played to reach the node, -1 for the root
+	pos      Position    // position after the move
+	over     bool        // whether the game ended with the move
+	result   float64     // score of the final position for the player who moved, when over
+	untried  []int       // candidate columns not expanded yet
+	children []*mctsNode // expanded moves
+	visits   int         // playouts through this node
+	reward   float64     // total score of those playouts for the player who moved into the node
+}
+
+// Name returns the display name
+func (b MCTSBot) Name() string { return b.Label }
+
+// Move runs the configured number of playouts and returns the most visited column
+func (b MCTSBot) Move(ctx context.Context, p Position) (int, MoveInfo) {
+	start := time.Now()
+	if _, ok := p.Winner(); ok || p.IsFull() {
+		return -1, MoveInfo{}
+	}
+	if c := immediateWin(&p); c >= 0 {
+		return c, MoveInfo{Score: 1000, Depth: 1, PV: []int{c}, Elapsed: time.Since(start)}
+	}
+
+	// derives the generator from the seed and the position so each move is reproducible
+	seed := b.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed ^ int64(p.Hash)))
+
+	root := newMCTSNode(nil, -1, p)
+	n := 0
+	for ; n < b.Playouts; n++ {
+		if n&255 == 0 && ctx.Err() != nil {
+			break
+		}
+
+		// selects a path with UCT until a node has untried moves
+		node := root
+		for len(node.untried) == 0 && len(node.children) > 0 {
+			node = node.selectChild(b.Exploration)
+		}
+
+		// expands one random untried move
+		if len(node.untried) > 0 && !node.over {
+			i := rng.Intn(len(node.untried))
+			col := node.untried[i]
+			node.untried = append(node.untried[:i], node.untried[i+1:]...)
+			child := newMCTSNode(node, col, apply(node.pos, col))
+			node.children = append(node.children, child)
+			node = child
+		}
+
+		// scores the node by a playout, then backs the result up the path
+		result := node.result
+		if !node.over {
+			result = 1 - rollout(node.pos, rng)
+		}
+		for ; node != nil; node = node.parent {
+			node.visits++
+			node.reward += result
+			result = 1 - result
+		}
+	}
+
+	// plays the most visited move and reports its line
+	best := root.mostVisited()
+	if best == nil {
+		return pickGreedy(&p), MoveInfo{Depth: 1, Elapsed: time.Since(start)}
+	}
+	info := MoveInfo{
+		Score:   int(math.Round((2*best.reward/float64(best.visits) - 1) * 1000)),
+		Nodes:   int64(n),
+		Elapsed: time.Since(start),
+	}
+	for node := best; node != nil; node = node.mostVisited() {
+		info.PV = append(info.PV, node.col)
+	}
+	info.Depth = len(info.PV)
+	return best.col, info
+}
+
+// newMCTSNode creates a node for the position reached by playing col from parent
+func newMCTSNode(parent *mctsNode, col int, p Position) *mctsNode {
+	n := &mctsNode{parent: parent, col: col, pos: p}
+	if w, ok := p.Winner(); ok {
+		n.over = true
+		n.result = 0
+		if w != p.Next {
+			n.result = 1
+		}
+		return n
+	}
+	if p.IsFull() {
+		n.over, n.result = true, 0.5
+		return n
+	}
+	n.untried = candidateMoves(&p)
+	return n
+}
+
+// candidateMoves returns the winning move if any, otherwise the moves that do not lose at once
+func candidateMoves(p *Position) []int {
+	if c := immediateWin(p); c >= 0 {
+		return []int{c}
+	}
+	safe := p.nonLosingMoves()
+	var out []int
+	for c := 0; c < Cols; c++ {
+		if p.CanPlay(c) && (safe == 0 || safe&colMask(c) != 0) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// selectChild returns the child with the best upper confidence bound
+func (n *mctsNode) selectChild(c float64) *mctsNode {
+	var best *mctsNode
+	bestScore := math.Inf(-1)
+	logN := math.Log(float64(n.visits))
+	for _, ch := range n.children {
+		sc := ch.reward/float64(ch.visits) + c*math.Sqrt(logN/float64(ch.visits))
+		if sc > bestScore {
+			best, bestScore = ch, sc
+		}
+	}
+	return best
+}
+
+// mostVisited returns the child with the most playouts, or nil without children
+func (n *mctsNode) mostVisited() *mctsNode {
+	var best *mctsNode
+	for _, ch := range n.children {
+		if best == nil || ch.visits > best.visits {
+			best = ch
+		}
+	}
+	return best
+}
+
+// rollout plays p to the end, taking wins when available and otherwise random safe moves,
+// and returns the score for the player to move in p
+func rollout(p Position, rng *rand.Rand) float64 {
+	me := p.Next
+	for {
+		if p.IsFull() {
+			return 0.5
+		}
+		if p.CanWinNext() {
+			if p.Next == me {
+				return 1
+			}
+			return 0
+		}
+		moves := candidateMoves(&p)
+		p.Play(moves[rng.Intn(len(moves))])
+	}
+}
@@ -0,0 +1,246 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrBadGrid indicates a grid string that does not describe a legal board
+	ErrBadGrid = errors.New("invalid grid")
+
+	// ErrBadPosition indicates a position string with a bad side to move or move count
+	ErrBadPosition = errors.New("invalid position")
+)
+
+// PlyError reports the ply, counted from 1, at which a move sequence failed
+type PlyError struct {
+	Ply int   // offending ply
+	Err error // reason, such as ErrColFull or ErrColOutOfRange
+}
+
+// Error returns the ply and the reason
+func (e *PlyError) Error() string { return fmt.Sprintf("ply %d: %v", e.Ply, e.Err) }
+
+// Unwrap returns the reason so errors.Is matches it
+func (e *PlyError) Unwrap() error { return e.Err }
+
+// cellChars maps cells to their grid characters
+var cellChars = [...]byte{Empty: '.', Player1: 'x', Player2: 'o', Player3: 'y', Player4: 'z', Wall: '#'}
+
+// CellChar returns the grid character of c
+func CellChar(c Cell) byte {
+	if int(c) < len(cellChars) {
+		return cellChars[c]
+	}
+	return '?'
+}
+
+// ParseCellChar returns the cell for a grid character
+func ParseCellChar(ch byte) (Cell, bool) {
+	for c, v := range cellChars {
+		if v == ch {
+			return Cell(c), true
+		}
+	}
+	return Empty, false
+}
+
+// FormatGrid writes the board rows from top to bottom separated by '/', using '.', 'x' and 'o', then 'y' and 'z' for players 3 and 4
+// and '#' for walls
+func FormatGrid(b *Board) string {
+	var sb strings.Builder
+	for r := 0; r < b.Rules.Rows; r++ {
+		if r > 0 {
+			sb.WriteByte('/')
+		}
+		for c := 0; c < b.Rules.Cols; c++ {
+			sb.WriteByte(CellChar(b.Grid[r][c]))
+		}
+	}
+	return sb.String()
+}
+
+// ParseGrid reads a classic board written by FormatGrid and rejects floating discs
+func ParseGrid(s string) (Board, error) {
+	return ParseGridWithRules(s, ClassicRules)
+}
+
+// ParseGridWithRules reads a board of the given rules written by FormatGrid and rejects floating discs
+func ParseGridWithRules(s string, rs Rules) (Board, error) {
+	b := NewBoardWithRules(rs)
+	rows := strings.Split(strings.TrimSpace(s), "/")
+	if len(rows) != rs.Rows {
+		return b, ErrBadGrid
+	}
+	for _, line := range rows {
+		if len(line) != rs.Cols {
+			return b, ErrBadGrid
+		}
+	}
+	for r, line := range rows {
+		for c := 0; c < rs.Cols; c++ {
+			v, ok := ParseCellChar(line[c])
+			if !ok || v == Wall && rs.PowerUps == 0 || v != Wall && int(v) > rs.PlayerCount() {
+				return b, ErrBadGrid
+			}
+			// a disc must rest on the bottom or on another disc
+			if v != Empty {
+				if r < rs.Rows-1 && rows[r+1][c] == CellChar(Empty) {
+					return b, ErrBadGrid
+				}
+				b.Moves++
+			}
+			b.set(r, c, v)
+		}
+	}
+	return b, nil
+}
+
+// FormatMoves writes columns as a move string of 1-indexed digits, such as "4453"
+func FormatMoves(cols []int) string {
+	var sb strings.Builder
+	for _, c := range cols {
+		sb.WriteString(strconv.Itoa(c + 1))
+	}
+	return sb.String()
+}
+
+// moveKindChars prefix the column of the move kinds other than drops in move lists
+var moveKindChars = [...]byte{PopMove: 'p', AnvilMove: 'a', WallMove: 'w', BombMove: 'b'}
+
+// FormatGameMoves writes moves like FormatMoves, other kinds as a letter followed by the column, e.g. "4453p4":
+// 'p' for pops, 'a' for anvils, 'w' for walls and 'b' for bombs
+func FormatGameMoves(moves []Move) string {
+	var sb strings.Builder
+	for _, m := range moves {
+		if m.Kind != DropMove && int(m.Kind) < len(moveKindChars) {
+			sb.WriteByte(moveKindChars[m.Kind])
+		}
+		sb.WriteString(strconv.Itoa(m.Col + 1))
+	}
+	return sb.String()
+}
+
+// MoveCols returns the columns of moves in order
+func MoveCols(moves []Move) []int {
+	out := make([]int, len(moves))
+	for i, m := range moves {
+		out[i] = m.Col
+	}
+	return out
+}
+
+// ParseMoves reads a move string of 1-indexed digits and checks it can be played from an empty classic board
+// failures are *PlyError values wrapping ErrColOutOfRange, ErrColFull or ErrGameOver
+func ParseMoves(s string) ([]int, error) {
+	s = strings.TrimSpace(s)
+	cols := make([]int, 0, len(s))
+	p := NewPosition(Player1)
+	for i := 0; i < len(s); i++ {
+		ply := i + 1
+		if _, ok := p.Winner(); ok || p.IsFull() {
+			return nil, &PlyError{Ply: ply, Err: ErrGameOver}
+		}
+		// anything but a column digit is out of range
+		c := int(s[i]) - '1'
+		if c < 0 || c >= Cols {
+			return nil, &PlyError{Ply: ply, Err: ErrColOutOfRange}
+		}
+		if !p.CanPlay(c) {
+			return nil, &PlyError{Ply: ply, Err: ErrColFull}
+		}
+		p.Play(c)
+		cols = append(cols, c)
+	}
+	return cols, nil
+}
+
+// GameFromMoves creates a classic game by playing a move string from an empty board, Player1 starting
+func GameFromMoves(s string) (*Game, error) {
+	cols, err := ParseMoves(s)
+	if err != nil {
+		return nil, err
+	}
+	g := NewGame()
+	for _, c := range cols {
+		if err := Play(g, c); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// FormatPosition writes a compact position: the rows from top to bottom separated by '/',
+// with digits for runs of empty cells, then the side to move and the number of discs, e.g. "7/7/7/7/7/3x3 o 1"
+// boards with other rules end with their rules, e.g. "8/8/8/8/8/8/4x3 o 1 8x7c5"
+func FormatPosition(b *Board, next Cell) string {
+	var sb strings.Builder
+	for r := 0; r < b.Rules.Rows; r++ {
+		if r > 0 {
+			sb.WriteByte('/')
+		}
+		run := 0
+		for c := 0; c < b.Rules.Cols; c++ {
+			if b.Grid[r][c] == Empty {
+				run++
+				continue
+			}
+			if run > 0 {
+				sb.WriteString(strconv.Itoa(run))
+				run = 0
+			}
+			sb.WriteByte(CellChar(b.Grid[r][c]))
+		}
+		if run > 0 {
+			sb.WriteString(strconv.Itoa(run))
+		}
+	}
+	fmt.Fprintf(&sb, " %c %d", CellChar(next), b.Moves)
+	if !b.Rules.IsClassic() {
+		fmt.Fprintf(&sb, " %s", b.Rules)
+	}
+	return sb.String()
+}
+
+// ParsePosition reads a position written by FormatPosition, also accepting '.' for single empty cells
+func ParsePosition(s string) (Board, Cell, error) {
+	f := strings.Fields(s)
+	if len(f) != 3 && len(f) != 4 {
+		return NewBoard(), Empty, ErrBadPosition
+	}
+	rs := ClassicRules
+	if len(f) == 4 {
+		var err error
+		if rs, err = ParseRules(f[3]); err != nil {
+			return NewBoard(), Empty, err
+		}
+	}
+
+	// expands the empty runs into the grid notation
+	var sb strings.Builder
+	for i := 0; i < len(f[0]); i++ {
+		ch := f[0][i]
+		if ch >= '1' && ch <= '9' {
+			sb.WriteString(strings.Repeat(string(CellChar(Empty)), int(ch-'0')))
+			continue
+		}
+		sb.WriteByte(ch)
+	}
+	b, err := ParseGridWithRules(sb.String(), rs)
+	if err != nil {
+		return b, Empty, err
+	}
+
+	// the side to move must be one of the rules' players with a disc count that fits its turn
+	next, ok := ParseCellChar(f[1][0])
+	if len(f[1]) != 1 || !ok || !CanBeNext(&b, next) {
+		return b, Empty, ErrBadPosition
+	}
+	if n, err := strconv.Atoi(f[2]); err != nil || n != b.Moves {
+		return b, Empty, ErrBadPosition
+	}
+	return b, next, nil
+}
@@ -1,19 +1,32 @@
 package game
 
+import "math/bits"
+
 // AddPeon adds a piece to the given column for the specified player and returns the row index
 func AddPeon(board *Board, col int, cell Cell) (int, error) {
 	// rejects columns outside bounds
-	if col < 0 || col >= Cols {
+	if col < 0 || col >= board.Rules.Cols {
 		return -1, ErrColOutOfRange
 	}
 	// rejects non playable cell values
-	if cell != Player1 && cell != Player2 {
+	if cell < Player1 || int(cell) > board.Rules.PlayerCount() {
 		return -1, ErrInvalidPlayer
 	}
+	// counts the column's discs on the bitboards of classic boards
+	if board.bitboarded() {
+		h := bits.OnesCount64((board.stones[0] | board.stones[1]) & colMask(col))
+		if h >= Rows {
+			return -1, ErrColFull
+		}
+		r := Rows - 1 - h
+		board.set(r, col, cell)
+		board.Moves++
+		return r, nil
+	}
 	// scans from bottom to top and tries to place the piece
-	for r := Rows - 1; r >= 0; r-- {
+	for r := board.Rules.Rows - 1; r >= 0; r-- {
 		if board.Grid[r][col] == Empty {
-			board.Grid[r][col] = cell
+			board.set(r, col, cell)
 			board.Moves++ // increments move counter
 			return r, nil
 		}
@@ -21,3 +34,106 @@ func AddPeon(board *Board, col int, cell Cell) (int, error) {
 	// reports a full column when no slots remain
 	return -1, ErrColFull
 }
+
+// PopPeon removes the bottom piece of the given column, which must belong to the specified player, and shifts the column down
+func PopPeon(board *Board, col int, cell Cell) error {
+	// rejects pops when the rules do not allow them
+	if !board.Rules.PopOut {
+		return ErrPopNotAllowed
+	}
+	// rejects columns outside bounds
+	if col < 0 || col >= board.Rules.Cols {
+		return ErrColOutOfRange
+	}
+	// rejects non playable cell values
+	if cell < Player1 || int(cell) > board.Rules.PlayerCount() {
+		return ErrInvalidPlayer
+	}
+	// only the player's own disc can leave the bottom row
+	bottom := board.Rules.Rows - 1
+	if board.Grid[bottom][col] != cell {
+		return ErrCannotPop
+	}
+	// moves every disc above one row down
+	for r := bottom; r > 0; r-- {
+		board.set(r, col, board.Grid[r-1][col])
+	}
+	board.set(0, col, Empty)
+	board.Moves--
+	return nil
+}
+
+// unpopPeon puts back a piece popped from the given column, shifting the column up
+func unpopPeon(board *Board, col int, cell Cell) {
+	for r := 0; r < board.Rules.Rows-1; r++ {
+		board.set(r, col, board.Grid[r+1][col])
+	}
+	board.set(board.Rules.Rows-1, col, cell)
+	board.Moves++
+}
+
+// DropAnvil drops an anvil for the specified player in the given column: it crushes every disc of the column
+// and lands on the bottom row as the player's piece, returning the crushed discs bottom first
+func DropAnvil(board *Board, col int, cell Cell) ([]Cell, error) {
+	// rejects columns outside bounds
+	if col < 0 || col >= board.Rules.Cols {
+		return nil, ErrColOutOfRange
+	}
+	// rejects non playable cell values
+	if cell < Player1 || int(cell) > board.Rules.PlayerCount() {
+		return nil, ErrInvalidPlayer
+	}
+	// clears the column from the bottom up
+	var crushed []Cell
+	for r := board.Rules.Rows - 1; r >= 0 && board.Grid[r][col] != Empty; r-- {
+		crushed = append(crushed, board.Grid[r][col])
+		board.set(r, col, Empty)
+	}
+	board.set(board.Rules.Rows-1, col, cell)
+	board.Moves += 1 - len(crushed)
+	return crushed, nil
+}
+
+// DropWall drops a neutral wall in the given column and returns the row index
+func DropWall(board *Board, col int) (int, error) {
+	// rejects columns outside bounds
+	if col < 0 || col >= board.Rules.Cols {
+		return -1, ErrColOutOfRange
+	}
+	// scans from bottom to top and tries to place the wall
+	for r := board.Rules.Rows - 1; r >= 0; r-- {
+		if board.Grid[r][col] == Empty {
+			board.set(r, col, Wall)
+			board.Moves++
+			return r, nil
+		}
+	}
+	return -1, ErrColFull
+}
+
+// DropBomb drops a bomb for the specified player in the given column: it blows up the opponent disc it lands on
+// and itself, returning the row and the removed disc
+func DropBomb(board *Board, col int, cell Cell) (int, Cell, error) {
+	// rejects columns outside bounds
+	if col < 0 || col >= board.Rules.Cols {
+		return -1, Empty, ErrColOutOfRange
+	}
+	// rejects non playable cell values
+	if cell < Player1 || int(cell) > board.Rules.PlayerCount() {
+		return -1, Empty, ErrInvalidPlayer
+	}
+	// finds the top disc, which must belong to another player
+	for r := 0; r < board.Rules.Rows; r++ {
+		v := board.Grid[r][col]
+		if v == Empty {
+			continue
+		}
+		if v == cell || v == Wall {
+			return -1, Empty, ErrNoTarget
+		}
+		board.set(r, col, Empty)
+		board.Moves--
+		return r, v, nil
+	}
+	return -1, Empty, ErrNoTarget
+}
@@ -0,0 +1,276 @@
+package game
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrBadPuzzle indicates a puzzle line that cannot be read
+var ErrBadPuzzle = errors.New("invalid puzzle line")
+
+// PuzzleKind tells what a puzzle asks for
+type PuzzleKind string
+
+const (
+	PuzzleWin      PuzzleKind = "win"  // win by force within N own moves
+	PuzzleOnlyMove PuzzleKind = "only" // play the only column that does not lose
+)
+
+// Puzzle is a classic position where the player to move has to find the win or the only move that holds
+type Puzzle struct {
+	Moves     string     // move string from the empty board, columns numbered from 1, also the puzzle id
+	Kind      PuzzleKind // what the puzzle asks for
+	N         int        // own moves to the win, 1 for only-move puzzles
+	Solutions []int      // columns, 0-indexed, that solve the first move
+	Rating    int        // difficulty, the rating of the weakest rated bot that finds the first move
+}
+
+// Position returns the puzzle position
+func (pz *Puzzle) Position() (Position, error) {
+	cols, err := ParseMoves(pz.Moves)
+	if err != nil {
+		return Position{}, err
+	}
+	p := NewPosition(Player1)
+	for _, c := range cols {
+		p.Play(c)
+	}
+	return p, nil
+}
+
+// Prompt returns the task shown to the solver
+func (pz *Puzzle) Prompt() string {
+	side := "X"
+	if len(pz.Moves)%2 == 1 {
+		side = "O"
+	}
+	switch {
+	case pz.Kind == PuzzleOnlyMove:
+		return side + " to play: find the only move that does not lose"
+	case pz.N == 1:
+		return side + " to play and win at once"
+	}
+	return fmt.Sprintf("%s to play and win in %d moves", side, pz.N)
+}
+
+// maxPuzzleN is the longest win a puzzle may ask for, in own moves
+const maxPuzzleN = 5
+
+// FindPuzzle returns the puzzle the position after moves makes, if any: a win within maxN own moves found by a search of 2*maxN-1 plies,
+// or a single column that the solver proves does not lose while every other one loses within as many plies;
+// wins in one move are only kept when minN is 1, and only-move puzzles that merely block an immediate threat are skipped
+func FindPuzzle(ctx context.Context, moves string, minN, maxN int) (Puzzle, bool) {
+	maxN = max(1, min(maxN, maxPuzzleN))
+	pz := Puzzle{Moves: moves}
+	p, err := pz.Position()
+	if err != nil {
+		return Puzzle{}, false
+	}
+	if _, ok := p.Winner(); ok || p.IsFull() {
+		return Puzzle{}, false
+	}
+	depth := 2*maxN - 1
+	a := AnalyzePosition(ctx, p, depth)
+	if a.Best < 0 || ctx.Err() != nil {
+		return Puzzle{}, false
+	}
+	if best := a.Columns[a.Best]; best.Outcome == OutcomeWin {
+		n := (best.Plies + 1) / 2
+		if n < minN || n > maxN {
+			return Puzzle{}, false
+		}
+		pz.Kind, pz.N = PuzzleWin, n
+		for _, ca := range a.Columns {
+			if ca.Legal && ca.Outcome == OutcomeWin && ca.Plies == best.Plies {
+				pz.Solutions = append(pz.Solutions, ca.Col)
+			}
+		}
+	} else {
+		// every column but one loses by force and it is not a plain block
+		only := -1
+		for _, ca := range a.Columns {
+			if !ca.Legal || ca.Outcome == OutcomeLoss {
+				continue
+			}
+			if only >= 0 {
+				return Puzzle{}, false
+			}
+			only = ca.Col
+		}
+		if only < 0 || p.opponentWinningSpots()&p.possible() != 0 || p.Moves < solverMinMoves {
+			return Puzzle{}, false
+		}
+		child := apply(p, only)
+		if !child.IsFull() && defaultSolver.Score(child) > 0 {
+			return Puzzle{}, false
+		}
+		pz.Kind, pz.N, pz.Solutions = PuzzleOnlyMove, 1, []int{only}
+	}
+	pz.Rating = puzzleRating(p, pz.Solutions)
+	return pz, true
+}
+
+// puzzleRating returns the rating at which the Elo bot plays one of the solutions in p half of the time,
+// interpolated between the steps of its curve, 200 above the strongest step when even that one misses
+func puzzleRating(p Position, solutions []int) int {
+	prevElo, prevChance := 0, 0.0
+	for i, s := range eloCurve {
+		chance := solveChance(p, s, solutions)
+		if chance >= 0.5 {
+			if i == 0 {
+				return s.Elo
+			}
+			t := (0.5 - prevChance) / (chance - prevChance)
+			return prevElo + int(t*float64(s.Elo-prevElo))
+		}
+		prevElo, prevChance = s.Elo, chance
+	}
+	_, hi := EloBotRange()
+	return hi + 200
+}
+
+// solveChance returns the probability that the Elo bot with the settings s plays one of the solutions in p
+func solveChance(p Position, s eloStep, solutions []int) float64 {
+	moves, scores := scoreRootMoves(context.Background(), &p, s.Depth)
+	if len(moves) == 0 {
+		return 0
+	}
+	best := 0
+	for i := range scores {
+		if scores[i] > scores[best] {
+			best = i
+		}
+	}
+	isSolution := func(i int) bool { return slices.Contains(solutions, moves[i]) }
+
+	// the softmax pick, or the best move without temperature
+	pick := 0.0
+	if s.Temperature > 0 {
+		total := 0.0
+		for i := range scores {
+			w := math.Exp(float64(scores[i]-scores[best]) / s.Temperature)
+			total += w
+			if isSolution(i) {
+				pick += w
+			}
+		}
+		pick /= total
+	} else if isSolution(best) {
+		pick = 1
+	}
+
+	// a blunder plays any column but the best one
+	blunder := 0.0
+	if len(moves) > 1 {
+		for i := range moves {
+			if i != best && isSolution(i) {
+				blunder++
+			}
+		}
+		blunder /= float64(len(moves) - 1)
+	} else {
+		return pick
+	}
+	return (1-s.Blunder)*pick + s.Blunder*blunder
+}
+
+// PuzzleMoveOK reports whether col keeps solving a puzzle of kind in p, with left own moves to win in for win puzzles
+func PuzzleMoveOK(p Position, kind PuzzleKind, left, col int) bool {
+	if !p.CanPlay(col) {
+		return false
+	}
+	if p.IsWinningMove(col) {
+		return true
+	}
+	// only-move answers get the exact solver, as FindPuzzle proved them, so no loss hides past a search horizon
+	if kind == PuzzleOnlyMove {
+		child := apply(p, col)
+		return child.IsFull() || defaultSolver.Score(child) <= 0
+	}
+	if left <= 1 {
+		return false
+	}
+	a := AnalyzePosition(context.Background(), p, 2*left-1)
+	ca := a.Columns[col]
+	return ca.Outcome == OutcomeWin && ca.Plies <= 2*left-1
+}
+
+// PuzzleReply returns the defence the server plays in a win puzzle: the column that delays the loss the longest
+func PuzzleReply(p Position, left int) int {
+	return AnalyzePosition(context.Background(), p, 2*left).Best
+}
+
+// FormatPuzzle writes pz as a puzzle file line: "<moves> <kind> <n> <solutions> <rating>", solutions 1-indexed and comma-separated
+func FormatPuzzle(pz Puzzle) string {
+	sols := make([]string, len(pz.Solutions))
+	for i, c := range pz.Solutions {
+		sols[i] = strconv.Itoa(c + 1)
+	}
+	return fmt.Sprintf("%s %s %d %s %d", pz.Moves, pz.Kind, pz.N, strings.Join(sols, ","), pz.Rating)
+}
+
+// WritePuzzles writes one puzzle per line after a comment describing the format
+func WritePuzzles(w io.Writer, puzzles []Puzzle) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# <moves> <kind: win or only> <own moves to win> <solution columns> <rating>")
+	for _, pz := range puzzles {
+		fmt.Fprintln(bw, FormatPuzzle(pz))
+	}
+	return bw.Flush()
+}
+
+// ReadPuzzles parses a puzzle file written by WritePuzzles, lines starting with '#' are comments
+func ReadPuzzles(r io.Reader) ([]Puzzle, error) {
+	var out []Puzzle
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		f := strings.Fields(text)
+		if len(f) != 5 {
+			return nil, fmt.Errorf("line %d: %w", line, ErrBadPuzzle)
+		}
+		pz := Puzzle{Moves: f[0], Kind: PuzzleKind(f[1])}
+		p, err := pz.Position()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		n, err1 := strconv.Atoi(f[2])
+		rating, err2 := strconv.Atoi(f[4])
+		if err1 != nil || err2 != nil || n < 1 || n > maxPuzzleN || (pz.Kind != PuzzleWin && pz.Kind != PuzzleOnlyMove) {
+			return nil, fmt.Errorf("line %d: %w", line, ErrBadPuzzle)
+		}
+		pz.N, pz.Rating = n, rating
+		for _, s := range strings.Split(f[3], ",") {
+			c, err := strconv.Atoi(s)
+			if err != nil || !p.CanPlay(c-1) {
+				return nil, fmt.Errorf("line %d: %w", line, ErrBadPuzzle)
+			}
+			pz.Solutions = append(pz.Solutions, c-1)
+		}
+		out = append(out, pz)
+	}
+	return out, sc.Err()
+}
+
+// LoadPuzzles reads the puzzle file at path
+func LoadPuzzles(path string) ([]Puzzle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPuzzles(f)
+}
@@ -0,0 +1,132 @@
+package game
+
+import (
+	"context"
+	"math"
+)
+
+// MoveClass grades a move against the best one in its position
+type MoveClass int
+
+const (
+	ClassBest       MoveClass = iota // the engine's choice, or as good
+	ClassGood                        // gives up little
+	ClassInaccuracy                  // gives up some winning chances
+	ClassMistake                     // gives up a lot
+	ClassBlunder                     // turns the game around
+)
+
+// moveClassNames are the labels of the move classes
+var moveClassNames = [...]string{"best", "good", "inaccuracy", "mistake", "blunder"}
+
+// String returns the move class label
+func (c MoveClass) String() string {
+	if int(c) < len(moveClassNames) {
+		return moveClassNames[c]
+	}
+	return "best"
+}
+
+// reviewScale is the number of evaluation points per unit of log-odds when scores become winning chances
+const reviewScale = 200
+
+// MoveReview is the verdict on one move of a game
+type MoveReview struct {
+	Ply    int       // move number, counted from 1
+	Player Cell      // player who moved
+	Col    int       // column played, 0-indexed
+	Best   int       // best column in the position
+	Class  MoveClass // grade of the move
+	Loss   float64   // winning chance given up compared with the best column, 0 to 1
+	Chance float64   // winning chance of Player1 after the move, 0 to 1, draws counting half
+}
+
+// Review grades every move of a classic game
+type Review struct {
+	Depth    int          // search depth of each position in plies
+	Moves    []MoveReview // one verdict per move in order
+	Start    float64      // winning chance of Player1 before the first move
+	Accuracy [3]float64   // average move accuracy in percent, indexed by Cell for Player1 and Player2
+}
+
+// winChance turns the analysis of a column into the chance the player to move scores with it, draws counting half
+func winChance(ca ColumnAnalysis) float64 {
+	switch ca.Outcome {
+	case OutcomeWin:
+		return 1
+	case OutcomeLoss:
+		return 0
+	case OutcomeDraw:
+		return 0.5
+	}
+	return 1 / (1 + math.Exp(-float64(ca.Score)/reviewScale))
+}
+
+// classifyLoss grades a move by the winning chance it gives up
+func classifyLoss(best bool, loss float64) MoveClass {
+	switch {
+	case best || loss < 0.01:
+		return ClassBest
+	case loss < 0.05:
+		return ClassGood
+	case loss < 0.10:
+		return ClassInaccuracy
+	case loss < 0.20:
+		return ClassMistake
+	}
+	return ClassBlunder
+}
+
+// moveAccuracy maps the winning chance a move gives up to an accuracy in percent, 100 for the best move
+func moveAccuracy(loss float64) float64 {
+	return max(0, min(100, 103.1668*math.Exp(-0.04354*100*loss)-3.1669))
+}
+
+// ReviewGame analyzes the position before every move of a classic game, given as 0-indexed columns with first moving first,
+// to depth plies and grades the moves; it returns ctx's error when stopped before the end
+func ReviewGame(ctx context.Context, first Cell, cols []int, depth int) (Review, error) {
+	rv := Review{Depth: depth, Start: 0.5}
+	p := NewPosition(first)
+	var total [3]float64
+	var count [3]int
+	for i, col := range cols {
+		if !p.CanPlay(col) {
+			return rv, ErrColFull
+		}
+		a := AnalyzePosition(ctx, p, depth)
+		if err := ctx.Err(); err != nil {
+			return rv, err
+		}
+		best := winChance(a.Columns[a.Best])
+		if i == 0 {
+			rv.Start = best
+		}
+		played := winChance(a.Columns[col])
+		loss := max(0, best-played)
+		chance := played
+		if p.Next == Player2 {
+			chance = 1 - played
+			if i == 0 {
+				rv.Start = 1 - best
+			}
+		}
+		rv.Moves = append(rv.Moves, MoveReview{
+			Ply:    i + 1,
+			Player: p.Next,
+			Col:    col,
+			Best:   a.Best,
+			Class:  classifyLoss(col == a.Best, loss),
+			Loss:   loss,
+			Chance: chance,
+		})
+		total[p.Next] += moveAccuracy(loss)
+		count[p.Next]++
+		p.Play(col)
+	}
+	for pl := Player1; pl <= Player2; pl++ {
+		if count[pl] > 0 {
+			rv.Accuracy[pl] = total[pl] / float64(count[pl])
+		}
+	}
+	return rv, nil
+}
@@ -0,0 +1,128 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	MaxCols = 9 // widest supported board, move strings use one digit per column
+	MaxRows = 8 // tallest supported board
+
+	MaxPlayers = 4 // most players sharing a free-for-all board
+
+	MaxPowerUps = 9 // most special discs of each kind a player can start with
+)
+
+// ErrBadRules indicates board dimensions or a win length outside the supported range
+var ErrBadRules = errors.New("invalid rules")
+
+// Rules sets the board size and the number of aligned discs that wins
+type Rules struct {
+	Cols     int  // board width
+	Rows     int  // board height
+	ToWin    int  // discs in a row needed to win
+	PopOut   bool // players may also pop one of their own discs out of the bottom row
+	Misere   bool // completing a line loses instead of winning
+	Players  int  // players taking turns in free-for-all games, up to MaxPlayers, zero for the usual two
+	PowerUps int  // anvils, walls and bombs each player starts with, zero for none
+}
+
+// ClassicRules are the standard 7x6 four-in-a-row rules, the only ones the bitboard engines play besides misère minimax
+var ClassicRules = Rules{Cols: Cols, Rows: Rows, ToWin: toWin}
+
+// Valid reports whether the board fits the grid and a line can be completed
+// two players leave Players at zero, free-for-all games play plain drops without PopOut or misère
+// and power-ups are for two-player games without PopOut
+func (r Rules) Valid() bool {
+	return r.Cols >= 1 && r.Cols <= MaxCols && r.Rows >= 1 && r.Rows <= MaxRows &&
+		r.ToWin >= 3 && r.ToWin <= max(r.Cols, r.Rows) &&
+		(r.Players == 0 || r.Players > 2 && r.Players <= MaxPlayers && !r.PopOut && !r.Misere) &&
+		(r.PowerUps == 0 || r.PowerUps > 0 && r.PowerUps <= MaxPowerUps && r.Players == 0 && !r.PopOut)
+}
+
+// PlayerCount returns the number of players taking turns
+func (r Rules) PlayerCount() int {
+	if r.Players == 0 {
+		return 2
+	}
+	return r.Players
+}
+
+// IsClassic reports whether r are the standard 7x6 four-in-a-row rules
+func (r Rules) IsClassic() bool { return r == ClassicRules }
+
+// IsClassicMisere reports whether r are the classic rules played misère, which the bitboard minimax also plays
+func (r Rules) IsClassicMisere() bool {
+	return r == Rules{Cols: Cols, Rows: Rows, ToWin: toWin, Misere: true}
+}
+
+// Cells returns the number of cells on the board
+func (r Rules) Cells() int { return r.Cols * r.Rows }
+
+// Name returns the display name of the variant with these rules, or describes the board size and win length
+func (r Rules) Name() string {
+	for _, v := range Variants() {
+		if v.Rules == r {
+			return v.Name
+		}
+	}
+	name := fmt.Sprintf("%d×%d, %d in a row", r.Cols, r.Rows, r.ToWin)
+	if r.PopOut {
+		name += ", PopOut"
+	}
+	if r.Misere {
+		name += ", misère"
+	}
+	if n := r.PlayerCount(); n > 2 {
+		name += fmt.Sprintf(", %d players", n)
+	}
+	if r.PowerUps > 0 {
+		name += fmt.Sprintf(", %d power-ups of each kind", r.PowerUps)
+	}
+	return name
+}
+
+// String writes the rules as "<cols>x<rows>c<toWin>", e.g. "8x7c5", then "n<players>" for more than two players
+// and "u<power-ups>" with power-ups, followed by 'p' for PopOut and 'm' for misère
+func (r Rules) String() string {
+	s := fmt.Sprintf("%dx%dc%d", r.Cols, r.Rows, r.ToWin)
+	if n := r.PlayerCount(); n > 2 {
+		s += fmt.Sprintf("n%d", n)
+	}
+	if r.PowerUps > 0 {
+		s += fmt.Sprintf("u%d", r.PowerUps)
+	}
+	if r.PopOut {
+		s += "p"
+	}
+	if r.Misere {
+		s += "m"
+	}
+	return s
+}
+
+// ParseRules reads rules written by String
+func ParseRules(s string) (Rules, error) {
+	var r Rules
+	size, misere := strings.CutSuffix(s, "m")
+	size, pop := strings.CutSuffix(size, "p")
+	r.PopOut, r.Misere = pop, misere
+	size, ups, _ := strings.Cut(size, "u")
+	if ups != "" {
+		if _, err := fmt.Sscanf(ups, "%d", &r.PowerUps); err != nil {
+			return Rules{}, ErrBadRules
+		}
+	}
+	size, players, _ := strings.Cut(size, "n")
+	if players != "" {
+		if _, err := fmt.Sscanf(players, "%d", &r.Players); err != nil {
+			return Rules{}, ErrBadRules
+		}
+	}
+	if _, err := fmt.Sscanf(size, "%dx%dc%d", &r.Cols, &r.Rows, &r.ToWin); err != nil || r.String() != s || !r.Valid() {
+		return Rules{}, ErrBadRules
+	}
+	return r, nil
+}
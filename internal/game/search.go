@@ -0,0 +1,409 @@
+package game
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// winScore is the score of winning with the next disc, reduced by one per extra ply
+	winScore = 1000000
+
+	// searchTableBits sets the transposition table size to 2^searchTableBits entries
+	searchTableBits = 18
+)
+
+// ttFlag tells how a stored score bounds the real one
+type ttFlag uint8
+
+const (
+	ttExact ttFlag = iota + 1
+	ttLower
+	ttUpper
+)
+
+type ttEntry struct {
+	key   uint64 // zobrist hash of the position
+	score int32  // stored score, win distances relative to the node
+	depth int8   // remaining depth the score was computed with
+	flag  ttFlag // bound type of score
+	move  int8   // best column found, -1 if none
+}
+
+// pack writes the entry without its key into one word
+func (e ttEntry) pack() uint64 {
+	return uint64(uint32(e.score)) | uint64(uint8(e.depth))<<32 | uint64(e.flag)<<40 | uint64(uint8(e.move))<<48
+}
+
+// unpackTT reads an entry written by pack back, key being the hash it was stored under
+func unpackTT(key, data uint64) ttEntry {
+	return ttEntry{key: key, score: int32(uint32(data)), depth: int8(data >> 32), flag: ttFlag(data >> 40), move: int8(data >> 48)}
+}
+
+// ttSlot holds one entry that threads searching together read and write without locks,
+// check being the key xor'ed with data so an entry torn by racing stores fails its check and is ignored
+type ttSlot struct {
+	check atomic.Uint64 // key ^ data
+	data  atomic.Uint64 // packed entry
+}
+
+// transTable is a transposition table shared by the threads of a search
+type transTable []ttSlot
+
+// probe returns the entry stored for key, if any
+func (t transTable) probe(key uint64) (ttEntry, bool) {
+	s := &t[key&(1<<searchTableBits-1)]
+	data := s.data.Load()
+	if s.check.Load()^data != key || ttFlag(data>>40) == 0 {
+		return ttEntry{}, false
+	}
+	return unpackTT(key, data), true
+}
+
+// store writes e over whatever entry shares its slot
+func (t transTable) store(e ttEntry) {
+	s := &t[e.key&(1<<searchTableBits-1)]
+	data := e.pack()
+	s.data.Store(data)
+	s.check.Store(e.key ^ data)
+}
+
+type searcher struct {
+	table    transTable      // transposition table kept between searches, shared with helper threads
+	nodes    int64           // nodes visited by the current search
+	ctx      context.Context // cancels the search when done
+	deadline time.Time       // stops the search when reached, zero for none
+	stopped  bool            // whether the search ran out of time
+}
+
+// searchers recycles searchers and their tables so concurrent games do not share state
+var searchers = sync.Pool{New: func() any {
+	return &searcher{table: make(transTable, 1<<searchTableBits)}
+}}
+
+// searchThreads is the number of threads a search uses, zero for one per CPU
+var searchThreads atomic.Int32
+
+// SetSearchThreads sets how many threads the searching bots use, zero or less for one per CPU
+func SetSearchThreads(n int) { searchThreads.Store(int32(max(n, 0))) }
+
+// SearchThreads returns the number of threads the searching bots use
+func SearchThreads() int {
+	if n := searchThreads.Load(); n > 0 {
+		return int(n)
+	}
+	return runtime.NumCPU()
+}
+
+// expired reports whether the deadline passed or the context is done
+func (s *searcher) expired() bool {
+	if s.ctx != nil && s.ctx.Err() != nil {
+		return true
+	}
+	return !s.deadline.IsZero() && time.Now().After(s.deadline)
+}
+
+// isMate reports whether score is a forced win or loss
+func isMate(score int) bool {
+	return absInt(score) > winScore-2*Rows*Cols
+}
+
+// toTT converts a score relative to the root into one relative to the node at ply
+func toTT(score, ply int) int {
+	if isMate(score) {
+		if score > 0 {
+			return score + ply
+		}
+		return score - ply
+	}
+	return score
+}
+
+// fromTT converts a stored score back to one relative to the root
+func fromTT(score, ply int) int {
+	if isMate(score) {
+		if score > 0 {
+			return score - ply
+		}
+		return score + ply
+	}
+	return score
+}
+
+// order returns the columns to explore, the remembered best move first then center first
+func order(first int) [Cols]int {
+	out := columnOrder
+	for i, c := range out {
+		if c == first {
+			copy(out[1:i+1], out[:i])
+			out[0] = first
+			break
+		}
+	}
+	return out
+}
+
+// negamax scores p for the player to move with alpha‑beta pruning and the transposition table
+func (s *searcher) negamax(p Position, depth, ply, alpha, beta int) int {
+	s.nodes++
+	if s.nodes&2047 == 0 && s.expired() {
+		s.stopped = true
+	}
+	if s.stopped {
+		return 0
+	}
+
+	// detects wins on this disc and draws on a full board
+	if p.CanWinNext() {
+		return winScore - ply
+	}
+	if p.Moves >= Rows*Cols-1 {
+		return 0
+	}
+	next := p.nonLosingMoves()
+	if next == 0 {
+		return -(winScore - ply - 1)
+	}
+	if depth <= 0 {
+		return eval(&p, p.Next)
+	}
+
+	// probes the transposition table
+	alphaOrig := alpha
+	first := -1
+	if e, ok := s.table.probe(p.Hash); ok {
+		first = int(e.move)
+		if int(e.depth) >= depth {
+			sc := fromTT(int(e.score), ply)
+			switch e.flag {
+			case ttExact:
+				return sc
+			case ttLower:
+				alpha = max(alpha, sc)
+			case ttUpper:
+				beta = min(beta, sc)
+			}
+			if alpha >= beta {
+				return sc
+			}
+		}
+	}
+
+	best, bestMove := math.MinInt32, -1
+	for _, c := range order(first) {
+		if next&colMask(c) == 0 {
+			continue
+		}
+		child := p
+		child.Play(c)
+		sc := -s.negamax(child, depth-1, ply+1, -beta, -alpha)
+		if s.stopped {
+			return 0
+		}
+		if sc > best {
+			best, bestMove = sc, c
+		}
+		alpha = max(alpha, sc)
+		if alpha >= beta {
+			break
+		}
+	}
+
+	// stores the result with its bound type
+	flag := ttExact
+	if best <= alphaOrig {
+		flag = ttUpper
+	} else if best >= beta {
+		flag = ttLower
+	}
+	s.table.store(ttEntry{key: p.Hash, score: int32(toTT(best, ply)), depth: int8(depth), flag: flag, move: int8(bestMove)})
+	return best
+}
+
+// root searches every playable column of p to depth and returns the best score and column
+func (s *searcher) root(p Position, depth int, first int) (int, int) {
+	alpha, beta := -winScore-1, winScore+1
+	best, bestMove := math.MinInt32, -1
+	for _, c := range order(first) {
+		if !p.CanPlay(c) {
+			continue
+		}
+		child := p
+		child.Play(c)
+		var sc int
+		if _, ok := child.Winner(); ok {
+			sc = winScore
+		} else if child.IsFull() {
+			sc = 0
+		} else {
+			sc = -s.negamax(child, depth-1, 1, -beta, -alpha)
+		}
+		if s.stopped {
+			return best, bestMove
+		}
+		if sc > best {
+			best, bestMove = sc, c
+		}
+		alpha = max(alpha, sc)
+	}
+	return best, bestMove
+}
+
+// pv follows the transposition table from col to rebuild the principal variation
+func (s *searcher) pv(p Position, col, depth int) []int {
+	var out []int
+	for col >= 0 && len(out) < depth && p.CanPlay(col) {
+		out = append(out, col)
+		p.Play(col)
+		if _, ok := p.Winner(); ok || p.IsFull() {
+			break
+		}
+		e, ok := s.table.probe(p.Hash)
+		if !ok {
+			break
+		}
+		col = int(e.move)
+	}
+	return out
+}
+
+// think runs an iterative deepening search on p from depth from up to maxDepth plies or until stopped
+func (s *searcher) think(p Position, from, maxDepth int) (int, MoveInfo) {
+	var info MoveInfo
+	if _, ok := p.Winner(); ok || p.IsFull() {
+		return -1, info
+	}
+
+	// keeps a sound move ready in case the first iteration does not finish
+	col := pickGreedy(&p)
+	if c := immediateWin(&p); c >= 0 {
+		info.Score, info.Depth, info.PV = winScore, 1, []int{c}
+		return c, info
+	}
+
+	for d := from; d <= maxDepth && d <= Rows*Cols-p.Moves; d++ {
+		sc, c := s.root(p, d, col)
+		if s.stopped {
+			break
+		}
+		col, info.Score, info.Depth = c, sc, d
+		if isMate(sc) {
+			// deeper iterations cannot change a proven result
+			break
+		}
+	}
+	info.Nodes = s.nodes
+	info.PV = s.pv(p, col, max(info.Depth, 1))
+	return col, info
+}
+
+// search runs a single-threaded search on p bounded by maxDepth, the budget when positive, and ctx
+func search(ctx context.Context, p Position, maxDepth int, budget time.Duration) (int, MoveInfo) {
+	return searchThreaded(ctx, p, maxDepth, budget, 1)
+}
+
+// searchThreaded runs a lazy SMP search on p: helper threads run the same iterative deepening,
+// every other one a ply ahead, and share the transposition table with the main thread so it finds
+// cutoffs and move orders sooner; the move comes from whichever thread completed the deepest iteration
+// negamax only knows normal play, so misère positions go to searchMisere instead
+func searchThreaded(ctx context.Context, p Position, maxDepth int, budget time.Duration, threads int) (int, MoveInfo) {
+	start := time.Now()
+	var deadline time.Time
+	if budget > 0 {
+		deadline = start.Add(budget)
+	}
+	if p.Misere {
+		col, info := searchMisere(ctx, p, maxDepth, deadline)
+		info.Elapsed = time.Since(start)
+		return col, info
+	}
+
+	s := searchers.Get().(*searcher)
+	defer searchers.Put(s)
+
+	// helpers stop when the main thread is done
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	type result struct {
+		col  int
+		info MoveInfo
+	}
+	helpers := make([]result, max(threads, 1)-1)
+	var wg sync.WaitGroup
+	for i := range helpers {
+		wg.Add(1)
+		go func(r *result, from int) {
+			defer wg.Done()
+			h := &searcher{table: s.table, ctx: ctx, deadline: deadline}
+			r.col, r.info = h.think(p, from, maxDepth)
+			r.info.Nodes = h.nodes
+		}(&helpers[i], 1+(i+1)%2)
+	}
+
+	s.nodes, s.stopped, s.ctx, s.deadline = 0, false, ctx, deadline
+	col, info := s.think(p, 1, maxDepth)
+	cancel()
+	wg.Wait()
+	for _, r := range helpers {
+		if r.col >= 0 && r.info.Depth > info.Depth {
+			col, info.Score, info.Depth, info.PV = r.col, r.info.Score, r.info.Depth, r.info.PV
+		}
+		info.Nodes += r.info.Nodes
+	}
+	info.Elapsed = time.Since(start)
+	s.ctx = nil
+	return col, info
+}
+
+// misereMaxDepth caps the plies of searchMisere, whose minimax has no transposition table to reach deeper
+const misereMaxDepth = 10
+
+// searchMisere searches a misère position with the minimax that honors it, one ply deeper at a time
+// up to maxDepth until the deadline passes or ctx is done, keeping the move of the deepest finished iteration
+func searchMisere(ctx context.Context, p Position, maxDepth int, deadline time.Time) (int, MoveInfo) {
+	var info MoveInfo
+	if _, ok := p.Winner(); ok || p.IsFull() {
+		return -1, info
+	}
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	col := -1
+	for d := 1; d <= min(maxDepth, misereMaxDepth, Rows*Cols-p.Moves); d++ {
+		moves, scores := scoreRootMoves(ctx, &p, d)
+		// an interrupted iteration scored its subtrees statically, so only the first one is kept
+		if ctx.Err() != nil && col >= 0 {
+			break
+		}
+		best := 0
+		for i := range scores {
+			if scores[i] > scores[best] {
+				best = i
+			}
+		}
+		col, info.Score, info.Depth = moves[best], scores[best], d
+		if ctx.Err() != nil || absInt(info.Score) > 100000-Rows*Cols {
+			// deeper iterations cannot change a proven result
+			break
+		}
+	}
+	info.PV = []int{col}
+	return col, info
+}
+
+// ComputeBotMoveWithin searches b for who until the budget elapses or ctx is done and returns the best column found
+func ComputeBotMoveWithin(ctx context.Context, b *Board, who Cell, budget time.Duration) int {
+	p, err := PositionFromBoard(b, who)
+	if err != nil {
+		return -1
+	}
+	col, _ := search(ctx, p, Rows*Cols, budget)
+	return col
+}
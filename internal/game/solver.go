@@ -0,0 +1,243 @@
+package game
+
+import "sync"
+
+const (
+	// MaxScore is the best solver score, a win with the first disc of the player to move
+	MaxScore = (Rows*Cols + 1) / 2
+
+	// MinScore is the worst solver score, a loss against the opponent's first disc
+	MinScore = -(Rows * Cols) / 2
+
+	// solverTableBits sets the transposition table size to 2^solverTableBits entries
+	solverTableBits = 21
+)
+
+// Solver computes exact game-theoretic values with a negamax search
+// scores are relative to the player to move: positive wins, zero draws, negative loses,
+// and the magnitude grows the earlier the game ends
+type Solver struct {
+	mu    sync.Mutex // serializes searches sharing the table
+	keys  []uint64   // transposition table keys
+	vals  []int8     // upper bounds stored with an offset, 0 means empty
+	Nodes int64      // nodes visited by the last search
+}
+
+// NewSolver creates a solver with its own transposition table
+func NewSolver() *Solver {
+	return &Solver{
+		keys: make([]uint64, 1<<solverTableBits),
+		vals: make([]int8, 1<<solverTableBits),
+	}
+}
+
+// columnOrder explores center columns first
+var columnOrder = genColumnOrder()
+
+// genColumnOrder generates column indexes from the center outwards
+func genColumnOrder() [Cols]int {
+	var out [Cols]int
+	for i := range out {
+		out[i] = Cols/2 + (1-2*(i%2))*(i+1)/2
+	}
+	return out
+}
+
+// slot returns the table index for key
+func slot(key uint64) uint64 {
+	return (key * 0x9E3779B97F4A7C15) >> (64 - solverTableBits)
+}
+
+// get returns the stored upper bound for key, or 0 when absent
+func (s *Solver) get(key uint64) int {
+	i := slot(key)
+	if s.keys[i] == key {
+		return int(s.vals[i])
+	}
+	return 0
+}
+
+// put stores an upper bound for key
+func (s *Solver) put(key uint64, val int) {
+	i := slot(key)
+	s.keys[i] = key
+	s.vals[i] = int8(val)
+}
+
+// Reset clears the transposition table
+func (s *Solver) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.keys)
+	clear(s.vals)
+}
+
+// negamax returns the score of p within [alpha, beta] assuming nobody can win immediately
+func (s *Solver) negamax(p Position, alpha, beta int) int {
+	s.Nodes++
+
+	next := p.nonLosingMoves()
+	if next == 0 {
+		// every move lets the opponent win
+		return -(Rows*Cols - p.Moves) / 2
+	}
+	if p.Moves >= Rows*Cols-2 {
+		// the last two discs cannot make a line anymore
+		return 0
+	}
+
+	// tightens the window with the earliest possible loss and win
+	lo := -(Rows*Cols - 2 - p.Moves) / 2
+	if alpha < lo {
+		alpha = lo
+		if alpha >= beta {
+			return alpha
+		}
+	}
+	hi := (Rows*Cols - 1 - p.Moves) / 2
+	if v := s.get(p.Key()); v != 0 {
+		hi = v + MinScore - 1
+	}
+	if beta > hi {
+		beta = hi
+		if alpha >= beta {
+			return beta
+		}
+	}
+
+	// orders candidate moves by the number of threats they create, center first on ties
+	var moves [Cols]uint64
+	var scores [Cols]int
+	n := 0
+	for i := Cols - 1; i >= 0; i-- {
+		m := next & colMask(columnOrder[i])
+		if m == 0 {
+			continue
+		}
+		sc := p.moveScore(m)
+		j := n
+		for ; j > 0 && scores[j-1] > sc; j-- {
+			moves[j], scores[j] = moves[j-1], scores[j-1]
+		}
+		moves[j], scores[j] = m, sc
+		n++
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		child := p
+		child.playMove(moves[i])
+		score := -s.negamax(child, -beta, -alpha)
+		if score >= beta {
+			return score
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	s.put(p.Key(), alpha-MinScore+1)
+	return alpha
+}
+
+// score computes the exact score of p with null-window searches
+func (s *Solver) score(p *Position) int {
+	if p.CanWinNext() {
+		return (Rows*Cols + 1 - p.Moves) / 2
+	}
+	lo := -(Rows*Cols - p.Moves) / 2
+	hi := (Rows*Cols + 1 - p.Moves) / 2
+	for lo < hi {
+		med := lo + (hi-lo)/2
+		if med <= 0 && lo/2 < med {
+			med = lo / 2
+		} else if med >= 0 && hi/2 > med {
+			med = hi / 2
+		}
+		if r := s.negamax(*p, med, med+1); r <= med {
+			hi = r
+		} else {
+			lo = r
+		}
+	}
+	return lo
+}
+
+// Score returns the exact score of p, which must not be over
+func (s *Solver) Score(p Position) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Nodes = 0
+	return s.score(&p)
+}
+
+// Solve returns the exact score of p and a best column, or -1 when the game is over
+func (s *Solver) Solve(p Position) (int, int) {
+	if _, ok := p.Winner(); ok || p.IsFull() {
+		return 0, -1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Nodes = 0
+
+	// wins at once when possible
+	for _, c := range columnOrder {
+		if p.CanPlay(c) && p.IsWinningMove(c) {
+			return (Rows*Cols + 1 - p.Moves) / 2, c
+		}
+	}
+
+	best := s.score(&p)
+	safe := p.nonLosingMoves()
+	fallback := -1
+	for _, c := range columnOrder {
+		if !p.CanPlay(c) {
+			continue
+		}
+		if fallback < 0 {
+			fallback = c
+		}
+		if safe&colMask(c) == 0 {
+			continue
+		}
+		child := p
+		child.Play(c)
+		if child.IsFull() {
+			return best, c
+		}
+		// a child at least as good as best proves the move
+		if -s.negamax(child, -best, -best+1) >= best {
+			return best, c
+		}
+	}
+	// every move loses at once
+	return best, fallback
+}
+
+// ScoreToResult turns a solver score of the player to move into a result and the plies until the end
+// result is 1 for a win, 0 for a draw and -1 for a loss, plies is 0 for a draw
+func ScoreToResult(p *Position, score int) (int, int) {
+	if score == 0 {
+		return 0, 0
+	}
+	if score > 0 {
+		// the winner plays their last disc after (Rows*Cols+1-moves)/2-score+1 own moves
+		own := (Rows*Cols+1-p.Moves)/2 - score + 1
+		return 1, 2*own - 1
+	}
+	own := (Rows*Cols-p.Moves)/2 + score + 1
+	return -1, 2 * own
+}
+
+// defaultSolver is shared by bots and analysis helpers
+var defaultSolver = NewSolver()
+
+// Solve returns the exact score and a best column for b with next to move using the shared solver,
+// the column being -1 when next is not a player
+func Solve(b *Board, next Cell) (int, int) {
+	p, err := PositionFromBoard(b, next)
+	if err != nil {
+		return 0, -1
+	}
+	return defaultSolver.Solve(p)
+}
@@ -0,0 +1,212 @@
+package game
+
+import (
+	"context"
+	"math"
+	"math/rand"
+)
+
+// TuneSample is a position labelled with the result its player to move reached: 1 win, 0.5 draw, 0 loss
+type TuneSample struct {
+	Pos    Position
+	Result float64
+}
+
+// SelfPlaySamples plays games with ComputeBotMove at level, each after randomPlies random moves,
+// labels every later position that is not over with the final result for its player to move,
+// and calls progress after each game
+func SelfPlaySamples(ctx context.Context, games, level, randomPlies int, rng *rand.Rand, progress func(done int)) []TuneSample {
+	var out []TuneSample
+	for i := 0; i < games && ctx.Err() == nil; i++ {
+		g := SelfPlayGame(level, randomPlies, rng)
+		p := NewPosition(Player1)
+		for _, c := range MoveCols(g.Moves) {
+			if p.Moves >= randomPlies {
+				out = append(out, TuneSample{Pos: p, Result: resultFor(g.Winner, p.Next)})
+			}
+			p.Play(c)
+		}
+		if progress != nil {
+			progress(i + 1)
+		}
+	}
+	return out
+}
+
+// SolverSamples plays random games and labels one position of each, with at least minPlies discs and no immediate win,
+// with its exact result from the solver, and calls progress after each sample
+func SolverSamples(ctx context.Context, count, minPlies int, rng *rand.Rand, progress func(done int)) []TuneSample {
+	s := NewSolver()
+	var out []TuneSample
+	for len(out) < count && ctx.Err() == nil {
+		p := NewPosition(Player1)
+		target := minPlies + rng.Intn(Cols*Rows-minPlies)
+		for p.Moves < target && !p.CanWinNext() {
+			ms := validMoves(&p)
+			p.Play(ms[rng.Intn(len(ms))])
+		}
+		// positions won in one move teach the weights nothing the search does not already see
+		if p.Moves < minPlies || p.IsFull() || p.CanWinNext() {
+			continue
+		}
+		res, _ := ScoreToResult(&p, s.Score(p))
+		out = append(out, TuneSample{Pos: p, Result: float64(res+1) / 2})
+		if progress != nil {
+			progress(len(out))
+		}
+	}
+	return out
+}
+
+// SelfPlayGame plays a classic game with ComputeBotMove at level for both sides after randomPlies random moves
+func SelfPlayGame(level, randomPlies int, rng *rand.Rand) *Game {
+	g := NewGame()
+	for !g.Over {
+		var col int
+		if len(g.Moves) < randomPlies {
+			p, _ := PositionFromBoard(&g.Board, g.NextPlayer) // self-play only has the two players
+			ms := validMoves(&p)
+			col = ms[rng.Intn(len(ms))]
+		} else {
+			col = ComputeBotMove(&g.Board, g.NextPlayer, level)
+		}
+		if Play(g, col) != nil {
+			break
+		}
+	}
+	return g
+}
+
+// resultFor returns the points of who in a game won by winner, Empty for a draw
+func resultFor(winner, who Cell) float64 {
+	switch winner {
+	case Empty:
+		return 0.5
+	case who:
+		return 1
+	}
+	return 0
+}
+
+// tuneFeatures returns the evaluation terms of the sample position for its player to move, followed by a constant term
+func tuneFeatures(s *TuneSample) [tuneDims]float64 {
+	var x [tuneDims]float64
+	for i, t := range evalTerms(&s.Pos, s.Pos.Next) {
+		x[i] = float64(t)
+	}
+	x[numEvalTerms] = 1
+	return x
+}
+
+// sigmoid maps log-odds to a probability
+func sigmoid(z float64) float64 { return 1 / (1 + math.Exp(-z)) }
+
+// EvalLogLoss returns the mean cross-entropy between the sample results and the win probability the weights predict,
+// scale being the number of evaluation points per unit of log-odds
+func EvalLogLoss(samples []TuneSample, w EvalWeights, scale float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	t := w.terms()
+	loss := 0.0
+	for i := range samples {
+		x := tuneFeatures(&samples[i])
+		z := 0.0
+		for j := range t {
+			z += float64(t[j]) * x[j] / scale
+		}
+		p := min(max(sigmoid(z), 1e-12), 1-1e-12)
+		y := samples[i].Result
+		loss -= y*math.Log(p) + (1-y)*math.Log(1-p)
+	}
+	return loss / float64(len(samples))
+}
+
+// TuneEvalWeights fits the weights by logistic regression of the sample results on the evaluation terms with Newton's method,
+// scale being the number of evaluation points per unit of log-odds; the constant term is fitted then dropped
+func TuneEvalWeights(samples []TuneSample, scale float64) EvalWeights {
+	const n = tuneDims
+	if len(samples) == 0 {
+		return DefaultEvalWeights
+	}
+	xs := make([][n]float64, len(samples))
+	for i := range samples {
+		xs[i] = tuneFeatures(&samples[i])
+	}
+	var theta [n]float64
+	for iter := 0; iter < 50; iter++ {
+		var grad [n]float64
+		var hess [n][n]float64
+		for i, x := range xs {
+			z := 0.0
+			for j := range x {
+				z += theta[j] * x[j]
+			}
+			p := sigmoid(z)
+			for j := range x {
+				grad[j] += (samples[i].Result - p) * x[j]
+				for k := range x {
+					hess[j][k] += p * (1 - p) * x[j] * x[k]
+				}
+			}
+		}
+		// a small ridge keeps the system solvable when a term never occurs
+		for j := range hess {
+			hess[j][j] += 1e-6 * float64(len(xs))
+			grad[j] -= 1e-6 * float64(len(xs)) * theta[j]
+		}
+		step, ok := solveLinear(hess, grad)
+		if !ok {
+			break
+		}
+		size := 0.0
+		for j := range theta {
+			theta[j] += step[j]
+			size = max(size, math.Abs(step[j]))
+		}
+		if size < 1e-9 {
+			break
+		}
+	}
+	var t [numEvalTerms]int
+	for j := range t {
+		t[j] = int(math.Round(theta[j] * scale))
+	}
+	return weightsFromTerms(t)
+}
+
+// tuneDims is the number of fitted coefficients, the evaluation terms and a constant
+const tuneDims = numEvalTerms + 1
+
+// solveLinear solves a·x = b by Gaussian elimination with partial pivoting, false when a is singular
+func solveLinear(a [tuneDims][tuneDims]float64, b [tuneDims]float64) ([tuneDims]float64, bool) {
+	for c := 0; c < tuneDims; c++ {
+		piv := c
+		for r := c + 1; r < tuneDims; r++ {
+			if math.Abs(a[r][c]) > math.Abs(a[piv][c]) {
+				piv = r
+			}
+		}
+		if math.Abs(a[piv][c]) < 1e-12 {
+			return b, false
+		}
+		a[c], a[piv] = a[piv], a[c]
+		b[c], b[piv] = b[piv], b[c]
+		for r := c + 1; r < tuneDims; r++ {
+			f := a[r][c] / a[c][c]
+			for k := c; k < tuneDims; k++ {
+				a[r][k] -= f * a[c][k]
+			}
+			b[r] -= f * b[c]
+		}
+	}
+	var x [tuneDims]float64
+	for r := tuneDims - 1; r >= 0; r-- {
+		s := b[r]
+		for k := r + 1; k < tuneDims; k++ {
+			s -= a[r][k] * x[k]
+		}
+		x[r] = s / a[r][r]
+	}
+	return x, true
+}
@@ -0,0 +1,152 @@
+package game
+
+import (
+	"context"
+	"sync"
+)
+
+// rating pools of the shipped variants, classic and misère named like the dedicated rating fields of auth users
+const (
+	PoolClassic = "classic"
+	PoolMisere  = "misere"
+	PoolSizes   = "sizes"
+	PoolPopOut  = "popout"
+	PoolArcade  = "arcade"
+	PoolParty3  = "party3"
+	PoolParty4  = "party4"
+)
+
+// poolNames are the display names of the shipped rating pools
+var poolNames = map[string]string{
+	PoolClassic: "Classic",
+	PoolMisere:  "Misère",
+	PoolSizes:   "Other sizes",
+	PoolPopOut:  "PopOut",
+	PoolArcade:  "Arcade",
+	PoolParty3:  "Three players",
+	PoolParty4:  "Four players",
+}
+
+// RatingPool is a rating pool and its display name
+type RatingPool struct {
+	ID   string // pool id stored with the ratings
+	Name string // display name
+}
+
+// MoveFunc chooses the move of who on b, stock holding the power-ups who has left
+type MoveFunc func(ctx context.Context, b *Board, who Cell, stock Stock) (MoveKind, int)
+
+// Variant bundles what rooms, matchmaking and ratings need to know about a way of playing
+type Variant struct {
+	ID       string                      // form value, stored on rooms
+	Name     string                      // display name
+	Rules    Rules                       // board rules
+	Pool     string                      // rating pool results are recorded in, empty for unrated variants
+	Template string                      // template file overriding the board controls, empty for the plain board
+	Bot      func(botID string) MoveFunc // bot factory, nil when bots cannot play the variant
+}
+
+// Matchmaking reports whether random matchmaking pairs players for v: rated two-player variants
+func (v Variant) Matchmaking() bool { return v.Pool != "" && v.Rules.PlayerCount() == 2 }
+
+// ClassicBot plays the classic board with the bot registered under botID, nil when none is
+func ClassicBot(botID string) MoveFunc {
+	bot, ok := LookupBot(botID)
+	if !ok {
+		return nil
+	}
+	return func(ctx context.Context, b *Board, who Cell, _ Stock) (MoveKind, int) {
+		p, err := PositionFromBoard(b, who)
+		if err != nil {
+			return DropMove, -1
+		}
+		col, _ := bot.Move(ctx, p)
+		return DropMove, col
+	}
+}
+
+// LevelBot plays any two-player rules with the built-in engines at the difficulty level of the bot registered under botID
+func LevelBot(botID string) MoveFunc {
+	level := BotLevel(botID)
+	return func(_ context.Context, b *Board, who Cell, stock Stock) (MoveKind, int) {
+		return ComputeBotAction(b, who, stock, level)
+	}
+}
+
+var (
+	variantsMu sync.RWMutex // guards variants
+
+	// variants holds the registered variants in registration order, classic first
+	variants = []Variant{
+		{ID: "classic", Name: "Classic 7×6, four in a row", Rules: ClassicRules, Pool: PoolClassic, Bot: ClassicBot},
+		{ID: "small", Name: "Small 6×5, four in a row", Rules: Rules{Cols: 6, Rows: 5, ToWin: 4}, Pool: PoolSizes, Bot: LevelBot},
+		{ID: "wide", Name: "Wide 9×7, four in a row", Rules: Rules{Cols: 9, Rows: 7, ToWin: 4}, Pool: PoolSizes, Bot: LevelBot},
+		{ID: "five", Name: "Large 8×7, five in a row", Rules: Rules{Cols: 8, Rows: 7, ToWin: 5}, Pool: PoolSizes, Bot: LevelBot},
+		{ID: "popout", Name: "PopOut 7×6, pop your own bottom discs", Rules: Rules{Cols: Cols, Rows: Rows, ToWin: toWin, PopOut: true}, Pool: PoolPopOut, Template: "variant_popout.tmpl", Bot: LevelBot},
+		{ID: "misere", Name: "Misère 7×6, four in a row loses", Rules: Rules{Cols: Cols, Rows: Rows, ToWin: toWin, Misere: true}, Pool: PoolMisere, Bot: LevelBot},
+		{ID: "arcade", Name: "Arcade 7×6, one anvil, wall and bomb each", Rules: Rules{Cols: Cols, Rows: Rows, ToWin: toWin, PowerUps: 1}, Pool: PoolArcade, Template: "variant_arcade.tmpl", Bot: LevelBot},
+		{ID: "party3", Name: "Three players 9×7, four in a row", Rules: Rules{Cols: 9, Rows: 7, ToWin: 4, Players: 3}, Pool: PoolParty3},
+		{ID: "party4", Name: "Four players 9×8, four in a row", Rules: Rules{Cols: 9, Rows: 8, ToWin: 4, Players: 4}, Pool: PoolParty4},
+	}
+)
+
+// RegisterVariant makes v available under v.ID, replacing any variant already registered with that id
+func RegisterVariant(v Variant) error {
+	if !v.Rules.Valid() {
+		return ErrBadRules
+	}
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	for i := range variants {
+		if variants[i].ID == v.ID {
+			variants[i] = v
+			return nil
+		}
+	}
+	variants = append(variants, v)
+	return nil
+}
+
+// LookupVariant returns the variant registered under id
+func LookupVariant(id string) (Variant, bool) {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	for _, v := range variants {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return Variant{}, false
+}
+
+// Variants returns the registered variants in registration order
+func Variants() []Variant {
+	variantsMu.RLock()
+	defer variantsMu.RUnlock()
+	return append([]Variant(nil), variants...)
+}
+
+// RatingPools returns the rating pools of the registered variants in registration order,
+// pools without a shipped name being named after their first variant
+func RatingPools() []RatingPool {
+	var out []RatingPool
+	seen := make(map[string]bool)
+	for _, v := range Variants() {
+		if v.Pool == "" || seen[v.Pool] {
+			continue
+		}
+		seen[v.Pool] = true
+		name, ok := poolNames[v.Pool]
+		if !ok {
+			name = v.Name
+		}
+		out = append(out, RatingPool{ID: v.Pool, Name: name})
+	}
+	return out
+}
+
+// ClassicVariant returns the classic variant, the fallback for missing or unknown ids
+func ClassicVariant() Variant {
+	v, _ := LookupVariant("classic")
+	return v
+}
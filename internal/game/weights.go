@@ -0,0 +1,134 @@
+package game
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrBadWeights indicates a weights line that cannot be read
+var ErrBadWeights = errors.New("invalid weights line")
+
+// EvalWeights holds the terms of the heuristic evaluation, in evaluation points
+type EvalWeights struct {
+	Three    int // window one disc short of a line for me, the rest empty
+	Two      int // window two discs short of a line for me, the rest empty
+	OppThree int // window one disc short of a line for the opponent
+	OppTwo   int // window two discs short of a line for the opponent
+	Center   int // each of my discs in the center column
+}
+
+// DefaultEvalWeights are the hand-picked weights used until a tuned file is loaded
+var DefaultEvalWeights = EvalWeights{Three: 100, Two: 10, OppThree: -120, OppTwo: -12, Center: 6}
+
+// evalWeights holds the weights every evaluation reads
+var evalWeights atomic.Pointer[EvalWeights]
+
+func init() {
+	w := DefaultEvalWeights
+	evalWeights.Store(&w)
+}
+
+// SetEvalWeights makes every engine evaluate with w
+func SetEvalWeights(w EvalWeights) { evalWeights.Store(&w) }
+
+// CurrentEvalWeights returns the weights the engines evaluate with
+func CurrentEvalWeights() EvalWeights { return *evalWeights.Load() }
+
+// weightNames lists the file keys of the weights in the order of terms
+var weightNames = [numEvalTerms]string{"three", "two", "opp_three", "opp_two", "center"}
+
+// numEvalTerms is the number of weighted terms of the evaluation
+const numEvalTerms = 5
+
+// terms returns the weights in the order of weightNames
+func (w EvalWeights) terms() [numEvalTerms]int {
+	return [numEvalTerms]int{w.Three, w.Two, w.OppThree, w.OppTwo, w.Center}
+}
+
+// weightsFromTerms builds weights from values in the order of weightNames
+func weightsFromTerms(t [numEvalTerms]int) EvalWeights {
+	return EvalWeights{Three: t[0], Two: t[1], OppThree: t[2], OppTwo: t[3], Center: t[4]}
+}
+
+// evalTerms counts the windows and center discs each weight applies to, for me on p
+func evalTerms(p *Position, me Cell) [numEvalTerms]int {
+	var t [numEvalTerms]int
+	mine := p.Stones[me-1]
+	theirs := p.Stones[opponent(me)-1]
+	t[4] = bits.OnesCount64(mine & colMask(Cols/2))
+	for _, w := range windows {
+		m, o := bits.OnesCount64(mine&w), bits.OnesCount64(theirs&w)
+		switch {
+		case m == toWin-1 && o == 0:
+			t[0]++
+		case m == toWin-2 && o == 0:
+			t[1]++
+		case o == toWin-1 && m == 0:
+			t[2]++
+		case o == toWin-2 && m == 0:
+			t[3]++
+		}
+	}
+	return t
+}
+
+// WriteTo writes w as one "<name> <value>" line per weight
+func (w EvalWeights) WriteTo(out io.Writer) (int64, error) {
+	bw := bufio.NewWriter(out)
+	var n int64
+	k, _ := fmt.Fprintln(bw, "# evaluation weights, one \"<name> <value>\" per line")
+	n += int64(k)
+	for i, v := range w.terms() {
+		k, _ = fmt.Fprintf(bw, "%s %d\n", weightNames[i], v)
+		n += int64(k)
+	}
+	return n, bw.Flush()
+}
+
+// ReadEvalWeights parses weights written by WriteTo, weights left out keep their default
+func ReadEvalWeights(r io.Reader) (EvalWeights, error) {
+	t := DefaultEvalWeights.terms()
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		f := strings.Fields(text)
+		if len(f) != 2 {
+			return EvalWeights{}, fmt.Errorf("line %d: %w", line, ErrBadWeights)
+		}
+		v, err := strconv.Atoi(f[1])
+		i := 0
+		for i < numEvalTerms && weightNames[i] != f[0] {
+			i++
+		}
+		if err != nil || i == numEvalTerms {
+			return EvalWeights{}, fmt.Errorf("line %d: %w", line, ErrBadWeights)
+		}
+		t[i] = v
+	}
+	if err := sc.Err(); err != nil {
+		return EvalWeights{}, err
+	}
+	return weightsFromTerms(t), nil
+}
+
+// LoadEvalWeights reads weights from the file at path
+func LoadEvalWeights(path string) (EvalWeights, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return EvalWeights{}, err
+	}
+	defer f.Close()
+	return ReadEvalWeights(f)
+}
@@ -0,0 +1,38 @@
+package game
+
+import "math/bits"
+
+// zobristDiscs holds a random key per player and cell, zobristSide is toggled whenever the turn passes
+var zobristDiscs, zobristSide = genZobrist()
+
+// genZobrist generates the zobrist keys with splitmix64 and a fixed seed so hashes are stable across runs
+func genZobrist() ([2][Cols * stride]uint64, uint64) {
+	seed := uint64(0x5EEDC0FFEE15600D)
+	next := func() uint64 {
+		seed += 0x9E3779B97F4A7C15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		return z ^ (z >> 31)
+	}
+	var discs [2][Cols * stride]uint64
+	for p := range discs {
+		for i := range discs[p] {
+			discs[p][i] = next()
+		}
+	}
+	return discs, next()
+}
+
+// discHash returns the zobrist key of a disc of player p on the single-bit cell
+func discHash(p Cell, cell uint64) uint64 {
+	return zobristDiscs[p-1][bits.TrailingZeros64(cell)]
+}
+
+// sideHash returns the zobrist key contribution of p being the player to move
+func sideHash(p Cell) uint64 {
+	if p == Player2 {
+		return zobristSide
+	}
+	return 0
+}
@@ -9,7 +9,7 @@ import (
 	"power4/internal/game"
 )
 
-// Play handles a POST move, validates turn and column, updates room state, and redirects back to the board
+// Play handles a POST move, a drop, a pop under PopOut or a power-up, validates turn and column, updates room state, and redirects back to the board
 func Play(w http.ResponseWriter, r *http.Request) {
 	// rejects non‑POST methods
 	if r.Method != http.MethodPost {
@@ -32,30 +32,29 @@ func Play(w http.ResponseWriter, r *http.Request) {
 
 	// enforces turn ownership per player id
 	pid := getOrSetPID(w, r)
-	if (rm.Game.NextPlayer == game.Player1 && rm.Player1ID != pid) ||
-		(rm.Game.NextPlayer == game.Player2 && rm.Player2ID != pid) {
+	if seatID(rm, rm.Game.NextPlayer) != pid {
 		http.Redirect(w, r, "/board/"+code, http.StatusSeeOther)
 		return
 	}
 
-	// parses target column and tries to play
-	col, _ := strconv.Atoi(strings.TrimSpace(r.FormValue("column")))
-	if err := game.Play(rm.Game, col); err == nil {
+	// parses target column and move kind and tries to play
+	kind, field := game.DropMove, "column"
+	if r.FormValue("pop") != "" {
+		kind, field = game.PopMove, "pop"
+	} else if k, ok := game.ParseMoveKind(r.FormValue("power")); ok && k != game.PopMove {
+		kind = k
+	}
+	col, _ := strconv.Atoi(strings.TrimSpace(r.FormValue(field)))
+	if err := game.PlayMove(rm.Game, kind, col); err == nil {
 		// updates revision and turn deadline
 		roomsMu.Lock()
 		rm.Rev++
 		rm.TurnDeadline = time.Now().Add(2 * time.Minute)
 		roomsMu.Unlock()
 
-		// generates Elo update if game just ended and both users are known
-		if rm.Game.Over && userStore != nil && rm.Player1User != "" && rm.Player2User != "" {
-			scoreA := 0.5
-			if rm.Game.Winner == game.Player1 {
-				scoreA = 1
-			} else if rm.Game.Winner == game.Player2 {
-				scoreA = 0
-			}
-			_ = userStore.ApplyMatch(rm.Player1User, rm.Player2User, scoreA, 32)
+		// generates Elo update if game just ended and every user is known
+		if rm.Game.Over && userStore != nil {
+			rateGame(rm)
 		}
 	}
 
@@ -0,0 +1,101 @@
+package httphandler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"power4/internal/game"
+)
+
+const (
+	// defaultAnalysisDepth is the search depth used when the request does not give one
+	defaultAnalysisDepth = 8
+
+	// maxAnalysisDepth caps the search depth a request may ask for
+	maxAnalysisDepth = 12
+)
+
+// ShowAnalysis writes the per-column evaluation of a position as JSON
+// /analyze/{code} analyzes the current position of a room, /analyze?grid=...&next=x a given position
+func ShowAnalysis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// reads the requested depth
+	q := r.URL.Query()
+	depth := defaultAnalysisDepth
+	if n, err := strconv.Atoi(q.Get("depth")); err == nil {
+		depth = max(1, min(n, maxAnalysisDepth))
+	}
+
+	var pos game.Position
+	if strings.Trim(strings.TrimPrefix(r.URL.Path, "/analyze"), "/") != "" {
+		// resolves the room from the URL
+		rm, code := roomFromPath(r.URL.Path)
+		if rm == nil {
+			NotFound(w, r)
+			return
+		}
+
+		// games can only be analyzed once over, bot games asking for counted hints while they are played
+		roomsMu.RLock()
+		over := rm.Game.Over
+		board, next := rm.Game.Board, rm.Game.NextPlayer
+		roomsMu.RUnlock()
+		if !over {
+			http.Redirect(w, r, "/board/"+code, http.StatusSeeOther)
+			return
+		}
+		if !board.Rules.IsClassic() {
+			http.Error(w, "analysis supports the classic board only", http.StatusBadRequest)
+			return
+		}
+		p, err := game.PositionFromBoard(&board, next)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pos = p
+	} else {
+		// reads the position from the query
+		board, err := game.ParseGrid(q.Get("grid"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next := game.Player1
+		if board.Moves%2 == 1 {
+			next = game.Player2
+		}
+		if s := q.Get("next"); s != "" {
+			c, ok := game.ParseCellChar(s[0])
+			// only the two classic players can be to move
+			if !ok || c != game.Player1 && c != game.Player2 || len(s) != 1 {
+				http.Error(w, "invalid next player", http.StatusBadRequest)
+				return
+			}
+			next = c
+		}
+		p, err := game.PositionFromBoard(&board, next)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		pos = p
+	}
+
+	if !acquireRequestSearch(r) {
+		http.Error(w, errEngineBusy.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer releaseSearch()
+	a := game.AnalyzePosition(r.Context(), pos, depth)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(a); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,124 @@
+package httphandler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"power4/internal/game"
+)
+
+// AskHint handles a POST hint request in a bot game: the engine picks the best move for the player to move
+// and explains it, the hint being counted so the game shows as assisted
+func AskHint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	// resolves room
+	rm, code := roomFromPath(r.URL.Path)
+	if rm == nil {
+		NotFound(w, r)
+		return
+	}
+
+	// only the human of a bot game, on their own turn
+	pid := getOrSetPID(w, r)
+	roomsMu.RLock()
+	allowed := rm.Bot && ready(rm) && !rm.Game.Over && !rm.BotThinking && seatID(rm, rm.Game.NextPlayer) == pid
+	board, who, stock, rev := rm.Game.Board, rm.Game.NextPlayer, rm.Game.Stock[rm.Game.NextPlayer], rm.Rev
+	roomsMu.RUnlock()
+	if !allowed {
+		http.Redirect(w, r, "/board/"+code, http.StatusSeeOther)
+		return
+	}
+
+	// searches outside the lock and keeps the hint only if nothing was played meanwhile
+	if acquireSearch(r.Context()) {
+		h, ok := game.SuggestMove(r.Context(), &board, who, stock)
+		releaseSearch()
+		if ok {
+			roomsMu.Lock()
+			if rm.Rev == rev {
+				rm.Hint, rm.HintRev = &h, rev
+				rm.Hints++
+			}
+			roomsMu.Unlock()
+		}
+	}
+	http.Redirect(w, r, "/board/"+code+"?rev="+strconv.Itoa(rm.Rev)+"&immediate=1&hold=1", http.StatusSeeOther)
+}
+
+// TakeBack handles a POST undo in a bot game: it takes back the bot's reply and the player's last move,
+// reopening a finished game, and counts the takeback so the game shows as assisted
+func TakeBack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	// resolves room
+	rm, code := roomFromPath(r.URL.Path)
+	if rm == nil {
+		NotFound(w, r)
+		return
+	}
+
+	pid := getOrSetPID(w, r)
+	roomsMu.Lock()
+	self := seatOf(rm, pid)
+	undone := false
+	if rm.Bot && self != game.Empty && !rm.BotThinking && playedBy(movesSinceStart(rm), self) {
+		// undoes down to the player's last move so it is their turn again, never into the setup moves of a custom start
+		for len(movesSinceStart(rm)) > 0 {
+			last := rm.Game.Moves[len(rm.Game.Moves)-1].Player
+			if game.Undo(rm.Game) != nil || last == self {
+				break
+			}
+		}
+		rm.Forfeit = ""
+		rm.Hint = nil
+		rm.Undos++
+		rm.TurnDeadline = time.Now().Add(2 * time.Minute)
+		rm.Rev++
+		undone = true
+	}
+	roomsMu.Unlock()
+
+	if undone {
+		notify(rm)
+	}
+	http.Redirect(w, r, "/board/"+code+"?rev="+strconv.Itoa(rm.Rev)+"&immediate=1&hold=1", http.StatusSeeOther)
+}
+
+// describeHint words a hint for the board, columns numbered from 1
+func describeHint(h game.Hint) string {
+	what := "column " + strconv.Itoa(h.Col+1)
+	if h.Kind != game.DropMove {
+		what = h.Kind.String() + " in " + what
+	}
+	if h.Reason == "" {
+		return what
+	}
+	return what + ", " + h.Reason
+}
+
+// movesSinceStart returns the moves of a room's game played after its start, leaving out the setup moves of a custom start
+func movesSinceStart(rm *Room) []game.Move {
+	n := 0
+	if rm.Start != nil {
+		n = min(len(rm.Start.Moves), len(rm.Game.Moves))
+	}
+	return rm.Game.Moves[n:]
+}
+
+// playedBy reports whether who played any of moves
+func playedBy(moves []game.Move, who game.Cell) bool {
+	for _, m := range moves {
+		if m.Player == who {
+			return true
+		}
+	}
+	return false
+}
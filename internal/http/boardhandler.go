@@ -38,17 +38,36 @@ func ShowBoard(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// lets the bot play when it is its turn and not holding
-	if rm.Bot && !rm.Game.Over && rm.Player2ID == "BOT" && rm.Game.NextPlayer == game.Player2 && !hold {
-		col := game.ComputeBotMove(&rm.Game.Board, game.Player2, rm.BotLevel)
-		if col >= 0 {
-			_ = game.Play(rm.Game, col)
+	// lets the bot play when it is its turn and not holding, one search at a time, in whichever seat it took
+	roomsMu.Lock()
+	botSeat := rm.Game.NextPlayer
+	botTurn := rm.Bot && !rm.BotThinking && !rm.Game.Over && seatID(rm, botSeat) == "BOT" && !hold
+	if botTurn {
+		rm.BotThinking = true
+	}
+	board := rm.Game.Board
+	stock := rm.Game.Stock[botSeat]
+	roomsMu.Unlock()
+	if botTurn {
+		// the variant builds the bot playing its rules
+		kind, col := game.DropMove, -1
+		if v := roomVariant(rm); v.Bot != nil && acquireSearch(r.Context()) {
+			if move := v.Bot(rm.BotID); move != nil {
+				kind, col = move(r.Context(), &board, botSeat, stock)
+			}
+			releaseSearch()
+		}
 
-			roomsMu.Lock()
+		roomsMu.Lock()
+		rm.BotThinking = false
+		played := col >= 0 && !rm.Game.Over && rm.Game.NextPlayer == botSeat && game.PlayMove(rm.Game, kind, col) == nil
+		if played {
 			rm.Rev++
 			rm.TurnDeadline = time.Now().Add(2 * time.Minute)
-			roomsMu.Unlock()
+		}
+		roomsMu.Unlock()
 
+		if played {
 			notify(rm)
 			http.Redirect(w, r, "/board/"+code+"?rev="+strconv.Itoa(rm.Rev)+"&immediate=1&m=1", http.StatusSeeOther)
 			return
@@ -77,50 +96,39 @@ func ShowBoard(w http.ResponseWriter, r *http.Request) {
 	// manages turn deadlines and possible forfeits
 	now := time.Now()
 	overNow := false
-	scoreA := 0.5
+	expired := false
 	roomsMu.Lock()
 	if ready(rm) && !rm.Game.Over {
 		if rm.TurnDeadline.IsZero() {
 			rm.TurnDeadline = now.Add(2 * time.Minute)
 			rm.Rev++
 		} else if now.After(rm.TurnDeadline) {
-			if rm.Game.NextPlayer == game.Player1 {
-				rm.Game.Over = true
-				rm.Game.Winner = game.Player2
-				scoreA = 0
-			} else {
-				rm.Game.Over = true
-				rm.Game.Winner = game.Player1
-				scoreA = 1
-			}
-			rm.Forfeit = "Time limit exceeded"
-			rm.Rev++
-			overNow = true
+			overNow = timeOut(rm, now)
+			expired = true
 		}
 	}
 	roomsMu.Unlock()
-	if overNow && userStore != nil && rm.Player1User != "" && rm.Player2User != "" {
-		_ = userStore.ApplyMatch(rm.Player1User, rm.Player2User, scoreA, 32)
+	if overNow && userStore != nil {
+		rateGame(rm)
+	}
+	if expired {
 		notify(rm)
 	}
 
 	// computes whether the current player can act
-	canPlay := false
-	if ready(rm) && !rm.Game.Over {
-		if rm.Game.NextPlayer == game.Player1 && rm.Player1ID == pid {
-			canPlay = true
-		}
-		if rm.Game.NextPlayer == game.Player2 && rm.Player2ID == pid {
-			canPlay = true
-		}
-	}
+	canPlay := ready(rm) && !rm.Game.Over && seatID(rm, rm.Game.NextPlayer) == pid
 
-	// prepares template with helpers
+	// prepares template with helpers, the variant's template file overriding the board controls
+	variant := roomVariant(rm)
+	files := []string{"board.tmpl"}
+	if variant.Template != "" {
+		files = append(files, variant.Template)
+	}
 	tmpl, err := template.New("").Funcs(template.FuncMap{
 		"Iterate":      Iterate,
 		"NextEmptyRow": NextEmptyRow,
 		"DropDuration": func(row int) int { return 280 + row*120 },
-	}).ParseFS(templateFS, "board.tmpl")
+	}).ParseFS(templateFS, files...)
 	if err != nil {
 		log.Printf("Template error: %v", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -129,20 +137,10 @@ func ShowBoard(w http.ResponseWriter, r *http.Request) {
 
 	// determines self player index
 	h := makeHeader(w, r)
-	self := 0
-	if pid == rm.Player1ID {
-		self = 1
-	} else if pid == rm.Player2ID {
-		self = 2
-	}
+	self := int(seatOf(rm, pid))
 
 	// infers the last player from who plays next
-	lastPlayer := game.Cell(0)
-	if rm.Game.NextPlayer == game.Player1 {
-		lastPlayer = game.Player2
-	} else if rm.Game.NextPlayer == game.Player2 {
-		lastPlayer = game.Player1
-	}
+	lastPlayer := game.PrevSeat(rm.Game.NextPlayer, seatCount(rm))
 
 	// signals animations for newly observed moves
 	if qrev != "" && cur < rm.Rev {
@@ -168,55 +166,158 @@ func ShowBoard(w http.ResponseWriter, r *http.Request) {
 	// validates that a last move is present
 	validLast := rm.Game.LastRow >= 0 && rm.Game.LastCol >= 0
 
+	// marks the discs of the winning lines
+	var winCells [game.MaxRows][game.MaxCols]bool
+	for _, l := range rm.Game.WinLines {
+		for _, pt := range l {
+			winCells[pt.Row][pt.Col] = true
+		}
+	}
+
+	// describes each column for the controls drawn under it, pops only under PopOut
+	rules := rm.Game.Board.Rules
+	columns := make([]columnView, rules.Cols)
+	for c := range columns {
+		columns[c] = columnView{
+			Col:      c,
+			Playable: canPlay,
+			Pop:      rules.PopOut && rm.Game.Board.Grid[rules.Rows-1][c] == rm.Game.NextPlayer,
+		}
+	}
+
+	// lists the seats for the HUD and the rematch panel
+	seats := make([]seatView, 0, seatCount(rm))
+	seated := 0
+	for p := game.Player1; int(p) <= seatCount(rm); p++ {
+		seats = append(seats, seatView{
+			Player:  int(p),
+			Name:    game.PlayerName(rm.Game, p),
+			Rematch: *rematchFlag(rm, p),
+			Out:     game.IsEliminated(rm.Game, p),
+		})
+		if seatID(rm, p) != "" {
+			seated++
+		}
+	}
+	selfRematch := self != 0 && *rematchFlag(rm, game.Cell(self))
+
+	// offers hints and takebacks to the player of a bot game, the hint lasting until the board changes
+	assist := rm.Bot && self != 0
+	hintCol, hintText := -1, ""
+	if canPlay && rm.Hint != nil && rm.HintRev == rm.Rev {
+		hintCol, hintText = rm.Hint.Col, describeHint(*rm.Hint)
+	}
+
+	// shows the special discs the viewer has left
+	var selfStock game.Stock
+	if self != 0 {
+		selfStock = rm.Game.Stock[self]
+	}
+
 	// renders the board
 	data := struct {
-		Code       string
-		Rev        int
-		Grid       [game.Rows][game.Cols]game.Cell
-		NextPlayer game.Cell
-		Over       bool
-		Winner     game.Cell
-		P1Name     string
-		P2Name     string
-		Ready      bool
-		CanPlay    bool
-		LoggedIn   bool
-		Username   string
-		Initials   string
-		CSRF       string
-		Forfeit    string
-		RematchP1  bool
-		RematchP2  bool
-		Self       int
-		LastRow    int
-		LastCol    int
-		LastPlayer game.Cell
-		IsNewMove  bool
-		HasLast    bool
+		Code        string
+		Rev         int
+		Grid        [game.MaxRows][game.MaxCols]game.Cell
+		Cols        int
+		Rows        int
+		RulesName   string
+		NextPlayer  game.Cell
+		Over        bool
+		Winner      game.Cell
+		P1Name      string
+		P2Name      string
+		NextName    string
+		WinnerName  string
+		Seats       []seatView
+		Seated      int
+		Ready       bool
+		CanPlay     bool
+		LoggedIn    bool
+		Username    string
+		Initials    string
+		CSRF        string
+		Forfeit     string
+		Self        int
+		SelfRematch bool
+		LastRow     int
+		LastCol     int
+		LastPlayer  game.Cell
+		IsNewMove   bool
+		HasLast     bool
+		WinCells    [game.MaxRows][game.MaxCols]bool
+		Columns     []columnView
+		Stock       game.Stock
+		CanCrush    bool
+		Wall        game.Cell
+		Unrated     bool
+		Reason      string
+		Reviewable  bool
+		Assist      bool
+		CanUndo     bool
+		HintCol     int
+		HintText    string
+		Hints       int
+		Undos       int
 	}{
-		Code:       rm.Code,
-		Rev:        rm.Rev,
-		Grid:       rm.Game.Board.Grid,
-		NextPlayer: rm.Game.NextPlayer,
-		Over:       rm.Game.Over,
-		Winner:     rm.Game.Winner,
-		P1Name:     rm.Game.Player1Name,
-		P2Name:     rm.Game.Player2Name,
-		Ready:      ready(rm),
-		CanPlay:    canPlay,
-		LoggedIn:   h.LoggedIn,
-		Username:   h.Username,
-		Initials:   h.Initials,
-		CSRF:       h.CSRF,
-		Forfeit:    rm.Forfeit,
-		RematchP1:  rm.RematchP1,
-		RematchP2:  rm.RematchP2,
-		Self:       self,
-		LastRow:    rm.Game.LastRow,
-		LastCol:    rm.Game.LastCol,
-		LastPlayer: lastPlayer,
-		IsNewMove:  isNewMove && validLast,
-		HasLast:    validLast,
+		Code:        rm.Code,
+		Rev:         rm.Rev,
+		Grid:        rm.Game.Board.Grid,
+		Cols:        rm.Game.Board.Rules.Cols,
+		Rows:        rm.Game.Board.Rules.Rows,
+		RulesName:   rulesName(rm.Game.Board.Rules),
+		NextPlayer:  rm.Game.NextPlayer,
+		Over:        rm.Game.Over,
+		Winner:      rm.Game.Winner,
+		P1Name:      rm.Game.Player1Name,
+		P2Name:      rm.Game.Player2Name,
+		NextName:    game.PlayerName(rm.Game, rm.Game.NextPlayer),
+		WinnerName:  game.PlayerName(rm.Game, rm.Game.Winner),
+		Seats:       seats,
+		Seated:      seated,
+		Ready:       ready(rm),
+		CanPlay:     canPlay,
+		LoggedIn:    h.LoggedIn,
+		Username:    h.Username,
+		Initials:    h.Initials,
+		CSRF:        h.CSRF,
+		Forfeit:     rm.Forfeit,
+		Self:        self,
+		SelfRematch: selfRematch,
+		LastRow:     rm.Game.LastRow,
+		LastCol:     rm.Game.LastCol,
+		LastPlayer:  lastPlayer,
+		IsNewMove:   isNewMove && validLast,
+		HasLast:     validLast,
+		WinCells:    winCells,
+		Columns:     columns,
+		Stock:       selfStock,
+		CanCrush:    canPlay && (selfStock.Anvils > 0 || selfStock.Bombs > 0),
+		Wall:        game.Wall,
+		Unrated:     rm.Unrated,
+		Reason:      rm.Game.Reason,
+		Reviewable:  rm.Game.Over && rm.Game.Board.Rules.IsClassic() && len(rm.Game.Moves) > 0 && setupPosition(rm) == "",
+		Assist:      assist,
+		CanUndo:     assist && !rm.BotThinking && playedBy(movesSinceStart(rm), game.Cell(self)),
+		HintCol:     hintCol,
+		HintText:    hintText,
+		Hints:       rm.Hints,
+		Undos:       rm.Undos,
 	}
 	_ = tmpl.ExecuteTemplate(w, "board", data)
 }
+
+// columnView describes a board column for the controls a variant draws under it
+type columnView struct {
+	Col      int  // column index
+	Playable bool // whether the viewer is to move in a running game
+	Pop      bool // whether the player to move may pop the bottom disc
+}
+
+// seatView describes a seat of the room on the board page
+type seatView struct {
+	Player  int    // player number, 1 to 4
+	Name    string // display name
+	Rematch bool   // rematch consent
+	Out     bool   // eliminated from a free-for-all game
+}
@@ -8,8 +8,6 @@ import (
 	"strconv"
 	"strings"
 	"time"
-
-	"power4/internal/game"
 )
 
 // ShowClock renders the per‑turn countdown and updates deadlines or forfeits when time elapses
@@ -34,7 +32,7 @@ func ShowClock(w http.ResponseWriter, r *http.Request) {
 	// checks and updates turn deadline
 	now := time.Now()
 	overNow := false
-	scoreA := 0.5
+	expired := false
 
 	roomsMu.Lock()
 	if ready(rm) && !rm.Game.Over {
@@ -42,18 +40,8 @@ func ShowClock(w http.ResponseWriter, r *http.Request) {
 			rm.TurnDeadline = now.Add(2 * time.Minute)
 			rm.Rev++
 		} else if now.After(rm.TurnDeadline) {
-			if rm.Game.NextPlayer == game.Player1 {
-				rm.Game.Over = true
-				rm.Game.Winner = game.Player2
-				scoreA = 0
-			} else {
-				rm.Game.Over = true
-				rm.Game.Winner = game.Player1
-				scoreA = 1
-			}
-			rm.Forfeit = "Time limit exceeded"
-			rm.Rev++
-			overNow = true
+			overNow = timeOut(rm, now)
+			expired = true
 		}
 	}
 
@@ -65,8 +53,10 @@ func ShowClock(w http.ResponseWriter, r *http.Request) {
 	roomsMu.Unlock()
 
 	// generates Elo update if a forfeit ended the game
-	if overNow && userStore != nil && rm.Player1User != "" && rm.Player2User != "" {
-		_ = userStore.ApplyMatch(rm.Player1User, rm.Player2User, scoreA, 32)
+	if overNow && userStore != nil {
+		rateGame(rm)
+	}
+	if expired {
 		notify(rm)
 	}
 
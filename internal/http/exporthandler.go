@@ -0,0 +1,80 @@
+package httphandler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"power4/internal/game"
+)
+
+// ExportGame downloads the current game of a room as text: players, result, move string and final position
+func ExportGame(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	roomsMu.RLock()
+	rm := rooms[code]
+	if rm == nil {
+		roomsMu.RUnlock()
+		NotFound(w, r)
+		return
+	}
+
+	// copies what the export needs while the room is locked
+	g := rm.Game
+	n := g.Board.Rules.PlayerCount()
+	names := make([]string, n)
+	for i := range names {
+		names[i] = game.PlayerName(g, game.Cell(i+1))
+	}
+	moves := game.FormatGameMoves(g.Moves)
+	position := game.FormatPosition(&g.Board, g.NextPlayer)
+	first := g.NextPlayer
+	if len(g.Moves) > 0 {
+		first = g.Moves[0].Player
+	}
+	// free-for-all results name the winner by disc character, draws being shared
+	result := "*"
+	if g.Over {
+		switch {
+		case g.Winner == game.Empty && n > 2:
+			result = "draw"
+		case n > 2:
+			result = string(game.CellChar(g.Winner))
+		case g.Winner == game.Player1:
+			result = "1-0"
+		case g.Winner == game.Player2:
+			result = "0-1"
+		default:
+			result = "1/2-1/2"
+		}
+	}
+	forfeit, reason := rm.Forfeit, g.Reason
+	hints, undos := rm.Hints, rm.Undos
+	setup := setupPosition(rm)
+	roomsMu.RUnlock()
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Game: %s\n", code)
+	for i, name := range names {
+		fmt.Fprintf(&sb, "Player%d: %s\n", i+1, name)
+	}
+	fmt.Fprintf(&sb, "First: %c\n", game.CellChar(first))
+	if setup != "" {
+		fmt.Fprintf(&sb, "Setup: %s\n", setup)
+	}
+	fmt.Fprintf(&sb, "Result: %s\n", result)
+	if forfeit != "" {
+		fmt.Fprintf(&sb, "Termination: %s\n", forfeit)
+	} else if reason != "" {
+		fmt.Fprintf(&sb, "Termination: %s\n", reason)
+	}
+	if hints > 0 || undos > 0 {
+		fmt.Fprintf(&sb, "Assisted: hints %d, undos %d\n", hints, undos)
+	}
+	fmt.Fprintf(&sb, "Moves: %s\n", moves)
+	fmt.Fprintf(&sb, "Position: %s\n", position)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="power4-`+code+`.txt"`)
+	_, _ = w.Write([]byte(sb.String()))
+}
@@ -422,6 +422,7 @@ func acceptChallenge(ticket, byUser, byPID string) (string, error) {
 	rm := &Room{
 		Code:         code,
 		Game:         game.NewGame(),
+		Variant:      game.ClassicVariant().ID,
 		Player1ID:    inv.ChallengerPID,
 		Player2ID:    byPID,
 		Player1User:  inv.Challenger,
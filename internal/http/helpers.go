@@ -3,6 +3,7 @@ package httphandler
 import (
 	"path"
 	"strings"
+	"time"
 
 	"power4/internal/game"
 	"power4/internal/util"
@@ -17,9 +18,9 @@ func Iterate(n int) []int {
 	return r
 }
 
-// NextEmptyRow returns the lowest empty row index in the given column or -1 if full
-func NextEmptyRow(grid [game.Rows][game.Cols]game.Cell, col int) int {
-	for r := game.Rows - 1; r >= 0; r-- {
+// NextEmptyRow returns the lowest empty row index in the given column of a board with rows rows or -1 if full
+func NextEmptyRow(grid [game.MaxRows][game.MaxCols]game.Cell, rows, col int) int {
+	for r := rows - 1; r >= 0; r-- {
 		if grid[r][col] == game.Empty {
 			return r
 		}
@@ -27,8 +28,157 @@ func NextEmptyRow(grid [game.Rows][game.Cols]game.Cell, col int) int {
 	return -1
 }
 
-// ready checks whether both player ids are set
-func ready(rm *Room) bool { return rm != nil && rm.Player1ID != "" && rm.Player2ID != "" }
+// rulesName returns the display name of non-classic rules, empty for the classic board
+func rulesName(r game.Rules) string {
+	if r.IsClassic() {
+		return ""
+	}
+	return r.Name()
+}
+
+// ready checks whether every seat of the room has a player id
+func ready(rm *Room) bool {
+	if rm == nil {
+		return false
+	}
+	for p := game.Player1; int(p) <= seatCount(rm); p++ {
+		if seatID(rm, p) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// seatCount returns the number of seats of the room, two unless the rules are free-for-all
+func seatCount(rm *Room) int { return rm.Game.Board.Rules.PlayerCount() }
+
+// seatID returns the pid seated as player p
+func seatID(rm *Room, p game.Cell) string {
+	switch p {
+	case game.Player1:
+		return rm.Player1ID
+	case game.Player2:
+		return rm.Player2ID
+	case game.Player3:
+		return rm.Player3ID
+	case game.Player4:
+		return rm.Player4ID
+	}
+	return ""
+}
+
+// seatUser returns the username seated as player p
+func seatUser(rm *Room, p game.Cell) string {
+	switch p {
+	case game.Player1:
+		return rm.Player1User
+	case game.Player2:
+		return rm.Player2User
+	case game.Player3:
+		return rm.Player3User
+	case game.Player4:
+		return rm.Player4User
+	}
+	return ""
+}
+
+// setSeat seats pid and username as player p and shows the username on the board
+func setSeat(rm *Room, p game.Cell, pid, username string) {
+	switch p {
+	case game.Player1:
+		rm.Player1ID, rm.Player1User = pid, username
+	case game.Player2:
+		rm.Player2ID, rm.Player2User = pid, username
+	case game.Player3:
+		rm.Player3ID, rm.Player3User = pid, username
+	case game.Player4:
+		rm.Player4ID, rm.Player4User = pid, username
+	}
+	game.SetPlayerName(rm.Game, p, username)
+}
+
+// rematchFlag returns the rematch consent of player p
+func rematchFlag(rm *Room, p game.Cell) *bool {
+	switch p {
+	case game.Player1:
+		return &rm.RematchP1
+	case game.Player2:
+		return &rm.RematchP2
+	case game.Player3:
+		return &rm.RematchP3
+	case game.Player4:
+		return &rm.RematchP4
+	}
+	return nil
+}
+
+// seatOf returns the player seated with pid, or Empty for spectators
+func seatOf(rm *Room, pid string) game.Cell {
+	for p := game.Player1; int(p) <= seatCount(rm); p++ {
+		if seatID(rm, p) == pid {
+			return p
+		}
+	}
+	return game.Empty
+}
+
+// roomVariant returns the variant played in the room, classic for rooms without a known one
+func roomVariant(rm *Room) game.Variant {
+	if v, ok := game.LookupVariant(rm.Variant); ok {
+		return v
+	}
+	return game.ClassicVariant()
+}
+
+// rateGame records the result of a finished rated room once every seat has a user, in the rating pool of its variant
+// free-for-all ratings move by pairwise matches between every two players, K shared among the opponents, the game counting once per player
+func rateGame(rm *Room) {
+	pool := roomVariant(rm).Pool
+	if rm.Unrated || pool == "" {
+		return
+	}
+	n := seatCount(rm)
+	for p := game.Player1; int(p) <= n; p++ {
+		if seatUser(rm, p) == "" {
+			return
+		}
+	}
+	places := game.Placings(rm.Game)
+	names := make([]string, n)
+	for p := game.Player1; int(p) <= n; p++ {
+		names[p-1] = seatUser(rm, p)
+	}
+	_ = userStore.ApplyPoolGame(pool, names, places[1:], 32)
+}
+
+// setupPosition returns the start position of a room's game when its moves do not begin on an empty board,
+// written by FormatPosition, and an empty string otherwise
+func setupPosition(rm *Room) string {
+	if rm.Start == nil || rm.Start.Board.Moves == len(rm.Start.Moves) {
+		return ""
+	}
+	return game.FormatPosition(&rm.Start.Board, rm.Start.NextPlayer)
+}
+
+// timeOut applies the turn time limit to the player to move, the caller holding roomsMu:
+// two-player games are forfeited and free-for-all players are eliminated, the others playing on
+// it reports whether the game just ended
+func timeOut(rm *Room, now time.Time) bool {
+	if seatCount(rm) > 2 {
+		_ = game.Eliminate(rm.Game, rm.Game.NextPlayer)
+		rm.TurnDeadline = now.Add(2 * time.Minute)
+		rm.Rev++
+		if rm.Game.Over {
+			rm.Forfeit = "Last player standing"
+		}
+		return rm.Game.Over
+	}
+	rm.Game.Over = true
+	rm.Game.Winner = game.PrevSeat(rm.Game.NextPlayer, 2)
+	rm.Forfeit = "Time limit exceeded"
+	rm.Rev++
+	return true
+}
 
 // genCode generates a unique 6‑char uppercase room code
 func genCode() string {
@@ -4,6 +4,8 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+
+	"power4/internal/game"
 )
 
 // ShowHome renders the home page and ensures a pid cookie exists
@@ -19,6 +21,8 @@ func ShowHome(w http.ResponseWriter, r *http.Request) {
 
 	h := makeHeader(w, r)
 	_ = tmpl.ExecuteTemplate(w, "base", struct {
+		Variants         []game.Variant
+		MatchVariants    []game.Variant
 		LoggedIn         bool
 		Username         string
 		Initials         string
@@ -26,6 +30,8 @@ func ShowHome(w http.ResponseWriter, r *http.Request) {
 		HasFriendAlerts  bool
 		FriendAlertCount int
 	}{
+		Variants:         game.Variants(),
+		MatchVariants:    matchVariants(),
 		LoggedIn:         h.LoggedIn,
 		Username:         h.Username,
 		Initials:         h.Initials,
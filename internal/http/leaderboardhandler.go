@@ -5,13 +5,23 @@ import (
 	"log"
 	"net/http"
 	"strings"
+
+	"power4/internal/game"
 )
 
-// ShowLeaderboard renders the Elo leaderboard with optional username filtering
+// ShowLeaderboard renders the Elo leaderboard with a column per rating pool, ranked by one pool, classic by default,
+// with optional username filtering
 func ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
-	// reads query and fetches users
+	// reads query and pool, unknown pools falling back to classic, and fetches users
 	q := strings.TrimSpace(r.URL.Query().Get("q"))
-	list := userStore.UsersByElo(q)
+	pools := game.RatingPools()
+	pool := pools[0]
+	for _, p := range pools {
+		if p.ID == r.URL.Query().Get("pool") {
+			pool = p
+		}
+	}
+	list := userStore.UsersByRating(pool.ID, q)
 
 	// parses template
 	tmpl, err := template.ParseFS(templateFS, "base.tmpl", "leaderboard.tmpl")
@@ -27,7 +37,7 @@ func ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
 	type row struct {
 		Rank     int
 		Username string
-		Elo      int
+		Elos     []int // Elo in every pool, in the order of the columns
 		Games    int
 		Wins     int
 		Losses   int
@@ -39,10 +49,14 @@ func ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
 		if u.Games > 0 {
 			wr = int((float64(u.Wins) / float64(u.Games)) * 100)
 		}
+		elos := make([]int, len(pools))
+		for j, p := range pools {
+			elos[j] = u.Rating(p.ID)
+		}
 		rows = append(rows, row{
 			Rank:     i + 1,
 			Username: u.Username,
-			Elo:      u.Elo,
+			Elos:     elos,
 			Games:    u.Games,
 			Wins:     u.Wins,
 			Losses:   u.Losses,
@@ -53,6 +67,8 @@ func ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
 	// renders page
 	_ = tmpl.ExecuteTemplate(w, "base", struct {
 		Query            string
+		Pools            []game.RatingPool
+		Pool             game.RatingPool
 		Rows             []row
 		LoggedIn         bool
 		Username         string
@@ -62,6 +78,8 @@ func ShowLeaderboard(w http.ResponseWriter, r *http.Request) {
 		FriendAlertCount int
 	}{
 		Query:            q,
+		Pools:            pools,
+		Pool:             pool,
 		Rows:             rows,
 		LoggedIn:         h.LoggedIn,
 		Username:         h.Username,
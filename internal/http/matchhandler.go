@@ -42,6 +42,45 @@ func abs(x int) int {
 	return x
 }
 
+// matchVariants lists the registered variants random matchmaking pairs players for
+func matchVariants() []game.Variant {
+	var out []game.Variant
+	for _, v := range game.Variants() {
+		if v.Matchmaking() {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// openMatchRoom creates the room of a random match in variant v, the waiting opponent op as player 1, and returns its code
+func openMatchRoom(v game.Variant, op *waiter, pid, username string) string {
+	code := genCode()
+	now := time.Now()
+	rm := &Room{
+		Code:         code,
+		Game:         game.NewGameWithRules(v.Rules),
+		Variant:      v.ID,
+		Player1ID:    op.PID,
+		Player2ID:    pid,
+		Player1User:  op.Username,
+		Player2User:  username,
+		CreatedAt:    now,
+		Rev:          1,
+		subs:         make(map[chan struct{}]struct{}),
+		Random:       true,
+		TurnDeadline: now.Add(2 * time.Minute),
+		StartNext:    game.Player2,
+	}
+	rm.Game.Player1Name = op.Username
+	rm.Game.Player2Name = username
+
+	roomsMu.Lock()
+	rooms[code] = rm
+	roomsMu.Unlock()
+	return code
+}
+
 // removeTicket removes a ticket from both queues and indexes
 func removeTicket(t string) {
 	mmMu.Lock()
@@ -71,7 +110,7 @@ func purgeUserFromQueue(username string) {
 	mmMu.Unlock()
 }
 
-// JoinRandom enqueues the user for matchmaking or pairs them immediately if a compatible opponent exists
+// JoinRandom enqueues the user in the queue of the chosen variant or pairs them immediately if a compatible opponent waits there
 func JoinRandom(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -89,35 +128,38 @@ func JoinRandom(w http.ResponseWriter, r *http.Request) {
 	}
 	pid := getOrSetPID(w, r)
 
-	// tries to find a compatible opponent
+	// reads the variant, classic when missing, unknown or not offered in matchmaking
+	v, ok := game.LookupVariant(r.FormValue("variant"))
+	if !ok || !v.Matchmaking() {
+		v = game.ClassicVariant()
+	}
+	elo := up.Rating(v.Pool)
+
 	mmMu.Lock()
-	for _, op := range waiting {
-		if op.PID == pid || op.Username == u.Username {
+	// reuses existing ticket if already queued for this variant, leaving the queues of other variants
+	i := 0
+	for i < len(waiting) {
+		wq := waiting[i]
+		if wq.Username == u.Username && wq.Variant == v.ID {
+			mmMu.Unlock()
+			http.Redirect(w, r, "/match/"+wq.Ticket, http.StatusSeeOther)
+			return
+		}
+		if wq.Username == u.Username {
+			delete(tickets, wq.Ticket)
+			waiting = append(waiting[:i], waiting[i+1:]...)
 			continue
 		}
-		if abs(op.Elo-up.Elo) <= rangeFor(op) {
-			code := genCode()
-			now := time.Now()
-			rm := &Room{
-				Code:         code,
-				Game:         game.NewGame(),
-				Player1ID:    op.PID,
-				Player2ID:    pid,
-				Player1User:  op.Username,
-				Player2User:  u.Username,
-				CreatedAt:    now,
-				Rev:          1,
-				subs:         make(map[chan struct{}]struct{}),
-				Random:       true,
-				TurnDeadline: now.Add(2 * time.Minute),
-				StartNext:    game.Player2,
-			}
-			rm.Game.Player1Name = op.Username
-			rm.Game.Player2Name = u.Username
+		i++
+	}
 
-			roomsMu.Lock()
-			rooms[code] = rm
-			roomsMu.Unlock()
+	// tries to find a compatible opponent in the same variant
+	for _, op := range waiting {
+		if op.PID == pid || op.Username == u.Username || op.Variant != v.ID {
+			continue
+		}
+		if abs(op.Elo-elo) <= rangeFor(op) {
+			code := openMatchRoom(v, op, pid, u.Username)
 
 			// removes matched opponent from queue
 			for i, wq := range waiting {
@@ -139,23 +181,14 @@ func JoinRandom(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// reuses existing ticket if already queued
-	for _, wq := range waiting {
-		if wq.Username == u.Username {
-			t := wq.Ticket
-			mmMu.Unlock()
-			http.Redirect(w, r, "/match/"+t, http.StatusSeeOther)
-			return
-		}
-	}
-
 	// enqueues a new waiter
 	t := token()
 	wr := &waiter{
 		Ticket:   t,
 		PID:      pid,
 		Username: u.Username,
-		Elo:      up.Elo,
+		Variant:  v.ID,
+		Elo:      elo,
 		Ch:       make(chan string, 1),
 		Created:  time.Now(),
 	}
@@ -185,6 +218,10 @@ func ShowMatch(w http.ResponseWriter, r *http.Request) {
 	}
 
 	rng := rangeFor(wr)
+	variant := wr.Variant
+	if v, ok := game.LookupVariant(wr.Variant); ok {
+		variant = v.Name
+	}
 	min2 := wr.Elo - rng
 	max2 := wr.Elo + rng
 
@@ -197,6 +234,7 @@ func ShowMatch(w http.ResponseWriter, r *http.Request) {
 	h := makeHeader(w, r)
 	_ = tmpl.ExecuteTemplate(w, "base", struct {
 		Ticket           string
+		Variant          string
 		YourElo          int
 		MinElo           int
 		MaxElo           int
@@ -208,6 +246,7 @@ func ShowMatch(w http.ResponseWriter, r *http.Request) {
 		FriendAlertCount int
 	}{
 		Ticket:           t,
+		Variant:          variant,
 		YourElo:          wr.Elo,
 		MinElo:           min2,
 		MaxElo:           max2,
@@ -238,38 +277,21 @@ func CheckMatch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// tries to pair immediately
+	// tries to pair immediately within the variant's queue
 	rng := rangeFor(wr)
 	for _, op := range waiting {
-		if op.Ticket == wr.Ticket {
+		if op.Ticket == wr.Ticket || op.Variant != wr.Variant {
 			continue
 		}
 		if op.Username == wr.Username || op.PID == wr.PID {
 			continue
 		}
 		if abs(op.Elo-wr.Elo) <= rng {
-			code := genCode()
-			now := time.Now()
-			rm := &Room{
-				Code:         code,
-				Game:         game.NewGame(),
-				Player1ID:    op.PID,
-				Player2ID:    wr.PID,
-				Player1User:  op.Username,
-				Player2User:  wr.Username,
-				CreatedAt:    now,
-				Rev:          1,
-				subs:         make(map[chan struct{}]struct{}),
-				Random:       true,
-				TurnDeadline: now.Add(2 * time.Minute),
-				StartNext:    game.Player2,
+			v, ok := game.LookupVariant(wr.Variant)
+			if !ok {
+				v = game.ClassicVariant()
 			}
-			rm.Game.Player1Name = op.Username
-			rm.Game.Player2Name = wr.Username
-
-			roomsMu.Lock()
-			rooms[code] = rm
-			roomsMu.Unlock()
+			code := openMatchRoom(v, op, wr.PID, wr.Username)
 
 			// remove both from queues
 			for i, wq := range waiting {
@@ -8,8 +8,15 @@ import (
 	"strings"
 
 	"power4/internal/auth"
+	"power4/internal/game"
 )
 
+// poolRating is a user's Elo in one rating pool other than classic
+type poolRating struct {
+	Name string // display name of the pool
+	Elo  int    // rating in the pool
+}
+
 // ShowProfile renders a user's public profile with friendship context
 func ShowProfile(w http.ResponseWriter, r *http.Request) {
 	// extracts the profile username from the URL
@@ -35,6 +42,14 @@ func ShowProfile(w http.ResponseWriter, r *http.Request) {
 		areFriends, outPending, inPending = friendsState(h.Username, u.Username)
 	}
 
+	// lists the ratings of the other pools, classic being the main Elo
+	var ratings []poolRating
+	for _, p := range game.RatingPools() {
+		if p.ID != game.PoolClassic {
+			ratings = append(ratings, poolRating{Name: p.Name, Elo: u.Rating(p.ID)})
+		}
+	}
+
 	// parses and renders the template
 	tmpl, err := template.ParseFS(templateFS, "base.tmpl", "profile.tmpl")
 	if err != nil {
@@ -55,6 +70,10 @@ func ShowProfile(w http.ResponseWriter, r *http.Request) {
 		Games           int
 		Wins            int
 		Losses          int
+		PuzzleElo       int
+		PuzzleSolves    int
+		Puzzles         int
+		Ratings         []poolRating
 		Self            string
 
 		AreFriends bool
@@ -73,6 +92,10 @@ func ShowProfile(w http.ResponseWriter, r *http.Request) {
 		Games:           u.Games,
 		Wins:            u.Wins,
 		Losses:          u.Losses,
+		PuzzleElo:       u.PuzzleElo,
+		PuzzleSolves:    u.PuzzleSolves,
+		Puzzles:         u.Puzzles,
+		Ratings:         ratings,
 		Self:            h.Username,
 
 		AreFriends: areFriends,
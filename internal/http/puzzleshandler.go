@@ -0,0 +1,280 @@
+package httphandler
+
+import (
+	"html/template"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"power4/internal/auth"
+	"power4/internal/game"
+)
+
+// puzzleK is the Elo K-factor of puzzle attempts
+const puzzleK = 24
+
+// puzzleWindow is the rating distance within which puzzles are picked at random for a user
+const puzzleWindow = 150
+
+// puzzleAttempt is the puzzle a user is solving and how far they got
+type puzzleAttempt struct {
+	Index  int           // puzzle index in puzzles
+	Pos    game.Position // current position, the user to move until the attempt is settled
+	Left   int           // own moves left to win in, win puzzles only
+	Moved  bool          // whether the user played a move yet
+	Busy   bool          // whether a move of the user is being checked, one at a time
+	Done   bool          // whether the attempt is settled
+	Solved bool          // whether the user solved the puzzle, once settled
+	Delta  int           // puzzle rating change, once settled
+}
+
+var (
+	puzzlesMu   sync.Mutex                        // guards puzzles, attempts and puzzlesSeen
+	puzzles     []game.Puzzle                     // puzzles loaded at boot
+	attempts    = make(map[string]*puzzleAttempt) // current attempt by user id
+	puzzlesSeen = make(map[string]map[int]bool)   // puzzles already given by user id
+)
+
+// SetPuzzles sets the puzzles offered by the puzzle mode
+func SetPuzzles(ps []game.Puzzle) {
+	puzzlesMu.Lock()
+	defer puzzlesMu.Unlock()
+	puzzles = ps
+	attempts = make(map[string]*puzzleAttempt)
+	puzzlesSeen = make(map[string]map[int]bool)
+}
+
+// pickPuzzle returns the index of an unseen puzzle near rating, the caller holding puzzlesMu:
+// a random one within puzzleWindow, else the closest, starting over once every puzzle was seen
+func pickPuzzle(userID string, rating int) int {
+	seen := puzzlesSeen[userID]
+	if seen == nil || len(seen) >= len(puzzles) {
+		seen = make(map[int]bool)
+		puzzlesSeen[userID] = seen
+	}
+	var near []int
+	closest := -1
+	for i, pz := range puzzles {
+		if seen[i] {
+			continue
+		}
+		d := abs(pz.Rating - rating)
+		if d <= puzzleWindow {
+			near = append(near, i)
+		}
+		if closest < 0 || d < abs(puzzles[closest].Rating-rating) {
+			closest = i
+		}
+	}
+	pick := closest
+	if len(near) > 0 {
+		pick = near[rand.Intn(len(near))]
+	}
+	seen[pick] = true
+	return pick
+}
+
+// newAttempt starts a new puzzle near the rating of u, the caller holding puzzlesMu
+func newAttempt(u *auth.User) *puzzleAttempt {
+	i := pickPuzzle(u.ID, u.PuzzleElo)
+	pos, err := puzzles[i].Position()
+	if err != nil {
+		log.Printf("puzzle %s: %v", puzzles[i].Moves, err)
+	}
+	a := &puzzleAttempt{Index: i, Pos: pos, Left: puzzles[i].N}
+	attempts[u.ID] = a
+	return a
+}
+
+// settle applies the settled attempt a of u on a puzzle of the given rating to the puzzle rating and records the change,
+// the caller not holding puzzlesMu since saving the store writes to disk
+func settle(u *auth.User, a *puzzleAttempt, rating int, solved bool) {
+	d, err := userStore.ApplyPuzzle(u.Username, rating, solved, puzzleK)
+	if err != nil {
+		log.Printf("puzzle rating error: %v", err)
+	}
+	puzzlesMu.Lock()
+	a.Delta = d
+	puzzlesMu.Unlock()
+}
+
+// ShowPuzzles renders the current puzzle of the user, starting one when needed
+func ShowPuzzles(w http.ResponseWriter, r *http.Request) {
+	u := auth.CurrentUser(userStore, r)
+	if u == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	puzzlesMu.Lock()
+	var a puzzleAttempt
+	var pz game.Puzzle
+	has := len(puzzles) > 0
+	if has {
+		cur := attempts[u.ID]
+		if cur == nil {
+			cur = newAttempt(u)
+		}
+		a, pz = *cur, puzzles[cur.Index]
+	}
+	puzzlesMu.Unlock()
+
+	tmpl, err := template.New("").Funcs(template.FuncMap{
+		"Iterate":      Iterate,
+		"NextEmptyRow": NextEmptyRow,
+	}).ParseFS(templateFS, "base.tmpl", "puzzles.tmpl")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h := makeHeader(w, r)
+
+	// lists the solutions, columns numbered from 1, once a failed attempt is settled
+	solution := ""
+	for i, c := range pz.Solutions {
+		if i > 0 {
+			solution += " or "
+		}
+		solution += strconv.Itoa(c + 1)
+	}
+	b := a.Pos.ToBoard()
+	_ = tmpl.ExecuteTemplate(w, "base", struct {
+		HasPuzzles       bool
+		Prompt           string
+		Grid             [game.MaxRows][game.MaxCols]game.Cell
+		Rows             int
+		Cols             int
+		NextPlayer       int
+		Done             bool
+		Solved           bool
+		Delta            int
+		PuzzleRating     int
+		Rating           int
+		Solution         string
+		LoggedIn         bool
+		Username         string
+		Initials         string
+		CSRF             string
+		HasFriendAlerts  bool
+		FriendAlertCount int
+	}{
+		HasPuzzles:       has,
+		Prompt:           pz.Prompt(),
+		Grid:             b.Grid,
+		Rows:             game.Rows,
+		Cols:             game.Cols,
+		NextPlayer:       int(a.Pos.Next),
+		Done:             a.Done,
+		Solved:           a.Solved,
+		Delta:            a.Delta,
+		PuzzleRating:     pz.Rating,
+		Rating:           u.PuzzleElo,
+		Solution:         solution,
+		LoggedIn:         h.LoggedIn,
+		Username:         h.Username,
+		Initials:         h.Initials,
+		CSRF:             h.CSRF,
+		HasFriendAlerts:  h.HasFriendAlerts,
+		FriendAlertCount: h.FriendAlertCount,
+	})
+}
+
+// PuzzleMove checks the column the user played: a wrong one fails the puzzle, the last right one solves it,
+// and otherwise the server answers with the longest defence
+func PuzzleMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !auth.CheckCSRF(r) {
+		http.Redirect(w, r, "/puzzles", http.StatusSeeOther)
+		return
+	}
+	u := auth.CurrentUser(userStore, r)
+	if u == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+	col, err := strconv.Atoi(r.FormValue("column"))
+	if err != nil {
+		http.Redirect(w, r, "/puzzles", http.StatusSeeOther)
+		return
+	}
+
+	// takes a search slot for the check and the reply before touching the attempt
+	if !acquireRequestSearch(r) {
+		http.Error(w, errEngineBusy.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	// copies the attempt out, one move at a time per user
+	puzzlesMu.Lock()
+	a := attempts[u.ID]
+	if a == nil || a.Done || a.Busy || !a.Pos.CanPlay(col) {
+		puzzlesMu.Unlock()
+		releaseSearch()
+		http.Redirect(w, r, "/puzzles", http.StatusSeeOther)
+		return
+	}
+	a.Busy, a.Moved = true, true
+	pz, pos, left := puzzles[a.Index], a.Pos, a.Left
+	puzzlesMu.Unlock()
+
+	// checks the move and searches the reply without the lock
+	settled, solved := true, false
+	switch {
+	case !game.PuzzleMoveOK(pos, pz.Kind, left, col):
+		pos.Play(col)
+	case pz.Kind == game.PuzzleOnlyMove || pos.IsWinningMove(col):
+		pos.Play(col)
+		solved = true
+	default:
+		pos.Play(col)
+		left--
+		pos.Play(game.PuzzleReply(pos, left))
+		settled = false
+	}
+	releaseSearch()
+
+	// commits unless the user moved on to another puzzle meanwhile
+	puzzlesMu.Lock()
+	a.Busy = false
+	current := attempts[u.ID] == a && !a.Done
+	if current {
+		a.Pos, a.Left = pos, left
+		a.Done, a.Solved = settled, solved
+	}
+	puzzlesMu.Unlock()
+	if current && settled {
+		settle(u, a, pz.Rating, solved)
+	}
+	http.Redirect(w, r, "/puzzles", http.StatusSeeOther)
+}
+
+// NextPuzzle moves on to a new puzzle, a started one left unsolved counting as failed
+func NextPuzzle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !auth.CheckCSRF(r) {
+		http.Redirect(w, r, "/puzzles", http.StatusSeeOther)
+		return
+	}
+	u := auth.CurrentUser(userStore, r)
+	if u == nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	puzzlesMu.Lock()
+	a := attempts[u.ID]
+	failed := a != nil && a.Moved && !a.Done
+	rating := 0
+	if failed {
+		a.Done = true
+		rating = puzzles[a.Index].Rating
+	}
+	if len(puzzles) > 0 {
+		newAttempt(u)
+	}
+	puzzlesMu.Unlock()
+	if failed {
+		settle(u, a, rating, false)
+	}
+	http.Redirect(w, r, "/puzzles", http.StatusSeeOther)
+}
@@ -8,7 +8,7 @@ import (
 	"power4/internal/game"
 )
 
-// Rematch records rematch consent and starts a new game when both sides agree or vs bot
+// Rematch records rematch consent and starts a new game when every seat agrees or vs bot
 func Rematch(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -34,50 +34,36 @@ func Rematch(w http.ResponseWriter, r *http.Request) {
 	changed := false
 
 	// records each player's consent once
-	if pid == rm.Player1ID && !rm.RematchP1 {
-		rm.RematchP1 = true
+	if p := seatOf(rm, pid); p != game.Empty && !*rematchFlag(rm, p) {
+		*rematchFlag(rm, p) = true
 		rm.Rev++
 		changed = true
 	}
-	if pid == rm.Player2ID && !rm.RematchP2 {
-		rm.RematchP2 = true
-		rm.Rev++
-		changed = true
-	}
-
-	// starts immediately for bot games, otherwise after both consent
-	start := false
-	if rm.Bot {
-		rm.RematchP1 = true
-		rm.RematchP2 = true
 
-		game.Reset(rm.Game)
-		rm.Game.NextPlayer = rm.StartNext
-		if rm.StartNext == game.Player1 {
-			rm.StartNext = game.Player2
-		} else {
-			rm.StartNext = game.Player1
-		}
-		rm.Forfeit = ""
-		rm.RematchP1 = false
-		rm.RematchP2 = false
-		rm.TurnDeadline = time.Now().Add(2 * time.Minute)
-		rm.Rev++
+	// starts immediately for bot games, otherwise after every seat consents
+	start := rm.Bot
+	if !start {
 		start = true
-	} else if rm.RematchP1 && rm.RematchP2 {
-		game.Reset(rm.Game)
-		rm.Game.NextPlayer = rm.StartNext
-		if rm.StartNext == game.Player1 {
-			rm.StartNext = game.Player2
+		for p := game.Player1; int(p) <= seatCount(rm); p++ {
+			start = start && *rematchFlag(rm, p)
+		}
+	}
+	if start {
+		// replays a custom start as it was, otherwise rotates who starts through the seats
+		if rm.Start != nil {
+			game.ResetTo(rm.Game, rm.Start)
 		} else {
-			rm.StartNext = game.Player1
+			game.Reset(rm.Game)
+			rm.Game.NextPlayer = rm.StartNext
+			rm.StartNext = game.NextSeat(rm.StartNext, seatCount(rm))
 		}
 		rm.Forfeit = ""
-		rm.RematchP1 = false
-		rm.RematchP2 = false
+		rm.Hints, rm.Undos, rm.Hint = 0, 0, nil
+		for p := game.Player1; int(p) <= seatCount(rm); p++ {
+			*rematchFlag(rm, p) = false
+		}
 		rm.TurnDeadline = time.Now().Add(2 * time.Minute)
 		rm.Rev++
-		start = true
 	}
 	roomsMu.Unlock()
 
@@ -0,0 +1,176 @@
+package httphandler
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+
+	"power4/internal/game"
+)
+
+const (
+	// reviewDepth is the search depth of every position of a review
+	reviewDepth = 8
+
+	// reviewCacheSize caps the number of cached reviews
+	reviewCacheSize = 256
+)
+
+var (
+	reviewMu    sync.Mutex                     // guards reviewCache
+	reviewCache = make(map[string]game.Review) // finished reviews by first player and move string
+)
+
+// cachedReview returns the review of the game played as cols from first, running the engine only the first time
+func cachedReview(r *http.Request, first game.Cell, cols []int) (game.Review, error) {
+	key := string(game.CellChar(first)) + game.FormatMoves(cols)
+	reviewMu.Lock()
+	rv, ok := reviewCache[key]
+	reviewMu.Unlock()
+	if ok {
+		return rv, nil
+	}
+	if !acquireRequestSearch(r) {
+		return rv, errEngineBusy
+	}
+	rv, err := game.ReviewGame(r.Context(), first, cols, reviewDepth)
+	releaseSearch()
+	if err != nil {
+		return rv, err
+	}
+	reviewMu.Lock()
+	// drops an arbitrary review once full
+	if len(reviewCache) >= reviewCacheSize {
+		for k := range reviewCache {
+			delete(reviewCache, k)
+			break
+		}
+	}
+	reviewCache[key] = rv
+	reviewMu.Unlock()
+	return rv, nil
+}
+
+// reviewRow is one move of the review table
+type reviewRow struct {
+	Ply    int    // move number
+	Player int    // player who moved, for the disc color
+	Name   string // name of the player who moved
+	Col    int    // column played, numbered from 1
+	Best   int    // best column, numbered from 1
+	Class  string // grade of the move
+	IsBest bool   // whether the move was the best one
+}
+
+// reviewGraph returns the SVG polyline points of Player1's winning chance, before the first move then after each move,
+// on a width by height canvas
+func reviewGraph(rv game.Review, width, height int) string {
+	var sb strings.Builder
+	n := max(len(rv.Moves), 1)
+	point := func(i int, chance float64) {
+		fmt.Fprintf(&sb, "%d,%d ", i*width/n, int(float64(height)*(1-chance)))
+	}
+	point(0, rv.Start)
+	for i, m := range rv.Moves {
+		point(i+1, m.Chance)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ShowReview renders the post-game review of a finished classic game: a grade per move, the evaluation graph and each player's accuracy
+func ShowReview(w http.ResponseWriter, r *http.Request) {
+	code := r.PathValue("code")
+	roomsMu.RLock()
+	rm := rooms[code]
+	if rm == nil {
+		roomsMu.RUnlock()
+		NotFound(w, r)
+		return
+	}
+	over, classic, setup := rm.Game.Over, rm.Game.Board.Rules.IsClassic(), setupPosition(rm)
+	cols := game.MoveCols(rm.Game.Moves)
+	names := [3]string{"", game.PlayerName(rm.Game, game.Player1), game.PlayerName(rm.Game, game.Player2)}
+	// rematches alternate who moves first
+	first := game.Player1
+	if len(rm.Game.Moves) > 0 {
+		first = rm.Game.Moves[0].Player
+	}
+	roomsMu.RUnlock()
+
+	if !over {
+		http.Error(w, "the review is available once the game is over", http.StatusForbidden)
+		return
+	}
+	if !classic {
+		http.Error(w, "reviews support the classic board only", http.StatusBadRequest)
+		return
+	}
+	if setup != "" {
+		http.Error(w, "reviews need a game started from an empty board", http.StatusBadRequest)
+		return
+	}
+
+	rv, err := cachedReview(r, first, cols)
+	if errors.Is(err, errEngineBusy) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]reviewRow, len(rv.Moves))
+	for i, m := range rv.Moves {
+		rows[i] = reviewRow{
+			Ply:    m.Ply,
+			Player: int(m.Player),
+			Name:   names[m.Player],
+			Col:    m.Col + 1,
+			Best:   m.Best + 1,
+			Class:  m.Class.String(),
+			IsBest: m.Class == game.ClassBest,
+		}
+	}
+
+	tmpl, err := template.ParseFS(templateFS, "base.tmpl", "review.tmpl")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h := makeHeader(w, r)
+	_ = tmpl.ExecuteTemplate(w, "base", struct {
+		Code             string
+		P1Name           string
+		P2Name           string
+		P1Accuracy       string
+		P2Accuracy       string
+		Graph            string
+		Rows             []reviewRow
+		Depth            int
+		LoggedIn         bool
+		Username         string
+		Initials         string
+		CSRF             string
+		HasFriendAlerts  bool
+		FriendAlertCount int
+	}{
+		Code:             code,
+		P1Name:           names[game.Player1],
+		P2Name:           names[game.Player2],
+		P1Accuracy:       fmt.Sprintf("%.1f", rv.Accuracy[game.Player1]),
+		P2Accuracy:       fmt.Sprintf("%.1f", rv.Accuracy[game.Player2]),
+		Graph:            reviewGraph(rv, 420, 120),
+		Rows:             rows,
+		Depth:            rv.Depth,
+		LoggedIn:         h.LoggedIn,
+		Username:         h.Username,
+		Initials:         h.Initials,
+		CSRF:             h.CSRF,
+		HasFriendAlerts:  h.HasFriendAlerts,
+		FriendAlertCount: h.FriendAlertCount,
+	})
+}
@@ -9,7 +9,8 @@ import (
 	"power4/internal/game"
 )
 
-// CreateRoom creates a private room and assigns the creator as player 1
+// CreateRoom creates a private room of the chosen variant and assigns the creator as player 1
+// power-up rooms are unrated unless the creator asks otherwise
 func CreateRoom(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -22,13 +23,20 @@ func CreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// reads the variant, classic when missing or unknown
+	v, ok := game.LookupVariant(r.FormValue("variant"))
+	if !ok {
+		v = game.ClassicVariant()
+	}
+
 	pid := getOrSetPID(w, r)
 	now := time.Now()
 	code := genCode()
 
 	rm := &Room{
 		Code:         code,
-		Game:         game.NewGame(),
+		Game:         game.NewGameWithRules(v.Rules),
+		Variant:      v.ID,
 		Player1ID:    pid,
 		CreatedAt:    now,
 		Rev:          1,
@@ -36,6 +44,7 @@ func CreateRoom(w http.ResponseWriter, r *http.Request) {
 		Random:       false,
 		TurnDeadline: time.Time{},
 		StartNext:    game.Player2,
+		Unrated:      v.Rules.PowerUps > 0 && r.FormValue("rated") == "",
 	}
 	rm.Player1User = u.Username
 	rm.Game.Player1Name = u.Username
@@ -47,7 +56,7 @@ func CreateRoom(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/game/"+code, http.StatusSeeOther)
 }
 
-// JoinRoom joins an existing room, filling player slots and starting the clock if both are present
+// JoinRoom joins an existing room, filling player slots and starting the clock once every seat is taken
 func JoinRoom(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/", http.StatusSeeOther)
@@ -66,7 +75,9 @@ func JoinRoom(w http.ResponseWriter, r *http.Request) {
 
 	roomsMu.Lock()
 	rm, ok := rooms[code]
-	if ok {
+	if ok && seatCount(rm) > 2 {
+		joinSeat(rm, pid, u.Username)
+	} else if ok {
 		// fills player 1 if empty or refreshes their profile info
 		if rm.Player1ID == "" {
 			if rm.Player1User == u.Username {
@@ -98,12 +109,12 @@ func JoinRoom(w http.ResponseWriter, r *http.Request) {
 			rm.Player2User = u.Username
 			rm.Game.Player2Name = u.Username
 		}
+	}
 
-		// starts the turn deadline once both players are present
-		if rm.Player1ID != "" && rm.Player2ID != "" && rm.TurnDeadline.IsZero() {
-			rm.TurnDeadline = now.Add(2 * time.Minute)
-			rm.Rev++
-		}
+	// starts the turn deadline once every player is present
+	if ok && ready(rm) && rm.TurnDeadline.IsZero() {
+		rm.TurnDeadline = now.Add(2 * time.Minute)
+		rm.Rev++
 	}
 	roomsMu.Unlock()
 
@@ -115,3 +126,25 @@ func JoinRoom(w http.ResponseWriter, r *http.Request) {
 	notify(rm)
 	http.Redirect(w, r, "/game/"+code, http.StatusSeeOther)
 }
+
+// joinSeat seats pid in the first free seat of a free-for-all room, or refreshes the username of a seated player
+// a user already seated from another device is left out so one account holds a single seat
+func joinSeat(rm *Room, pid, username string) {
+	if p := seatOf(rm, pid); p != game.Empty {
+		setSeat(rm, p, pid, username)
+		return
+	}
+	free := game.Empty
+	for p := game.Player1; int(p) <= seatCount(rm); p++ {
+		if seatUser(rm, p) == username {
+			return
+		}
+		if free == game.Empty && seatID(rm, p) == "" {
+			free = p
+		}
+	}
+	if free != game.Empty {
+		setSeat(rm, free, pid, username)
+		rm.Rev++
+	}
+}
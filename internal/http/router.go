@@ -49,10 +49,17 @@ func NewRouter(staticFS fs.FS) *nethttp.ServeMux {
 	mux.HandleFunc("/rooms/create", CreateRoom)
 	mux.HandleFunc("/rooms/join", JoinRoom)
 	mux.HandleFunc("/game/", ShowGame)
+	mux.HandleFunc("/game/{code}/export", ExportGame)
 	mux.HandleFunc("/board/", ShowBoard)
 	mux.HandleFunc("/clock/", ShowClock)
 	mux.HandleFunc("/play/", Play)
 	mux.HandleFunc("/rematch/", Rematch)
+	mux.HandleFunc("/hint/", AskHint)
+	mux.HandleFunc("/undo/", TakeBack)
+
+	// position analysis
+	mux.HandleFunc("/analyze", ShowAnalysis)
+	mux.HandleFunc("/analyze/", ShowAnalysis)
 
 	// random matchmaking
 	mux.HandleFunc("/match/join", JoinRandom)
@@ -78,6 +85,12 @@ func NewRouter(staticFS fs.FS) *nethttp.ServeMux {
 	mux.HandleFunc("/training", ShowTraining)
 	mux.HandleFunc("/training/start", StartTraining)
 
+	// puzzle mode
+	mux.HandleFunc("/puzzles", ShowPuzzles)
+	mux.HandleFunc("/puzzles/move", PuzzleMove)
+	mux.HandleFunc("/puzzles/next", NextPuzzle)
+	mux.HandleFunc("/review/{code}", ShowReview)
+
 	// static assets
 	mux.Handle("/static/", nethttp.StripPrefix("/static/", NewStaticHandler(staticFS)))
 	mux.HandleFunc("/static/css/assets/connect4.png", func(w nethttp.ResponseWriter, r *nethttp.Request) {
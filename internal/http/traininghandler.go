@@ -2,23 +2,89 @@ package httphandler
 
 import (
 	"html/template"
+	"math/rand"
 	"net/http"
-	"strconv"
+	"strings"
 	"time"
 
 	"power4/internal/auth"
 	"power4/internal/game"
 )
 
+// botOption describes a registered bot offered on the training page
+type botOption struct {
+	ID   string // registry id posted by the form
+	Name string // display name
+}
+
+// trainingBots lists the registered bots in registration order
+func trainingBots() []botOption {
+	ids := game.BotIDs()
+	out := make([]botOption, 0, len(ids))
+	for _, id := range ids {
+		if b, ok := game.LookupBot(id); ok {
+			out = append(out, botOption{ID: id, Name: b.Name()})
+		}
+	}
+	return out
+}
+
+// nearRatingBot is the form value asking for an EloBot targeting the player's rating
+const nearRatingBot = "rating"
+
+// trainingVariants lists the registered variants the bots can play
+func trainingVariants() []game.Variant {
+	var out []game.Variant
+	for _, v := range game.Variants() {
+		if v.Bot != nil {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
 // ShowTraining renders the training mode selector
 func ShowTraining(w http.ResponseWriter, r *http.Request) {
+	renderTraining(w, r, "", http.StatusOK)
+}
+
+// emptyTrainingGrid prefills the start grid editor with an empty classic board, rows from top to bottom
+var emptyTrainingGrid = strings.Repeat(".......\n", game.Rows-1) + "......."
+
+// renderTraining renders the training page with an optional error, showing the posted custom game form again
+func renderTraining(w http.ResponseWriter, r *http.Request, errorMsg string, status int) {
 	tmpl, err := template.ParseFS(templateFS, "base.tmpl", "training.tmpl")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	h := makeHeader(w, r)
+	rating := 0
+	if u := auth.CurrentUser(userStore, r); u != nil {
+		rating = u.Elo
+	}
+	variantID := strings.TrimSpace(r.FormValue("variant"))
+	if variantID == "" {
+		variantID = "classic"
+	}
+	grid := r.FormValue("grid")
+	if strings.TrimSpace(grid) == "" {
+		grid = emptyTrainingGrid
+	}
+	if status > 0 {
+		w.WriteHeader(status)
+	}
 	_ = tmpl.ExecuteTemplate(w, "base", struct {
+		Bots             []botOption
+		Error            string
+		Moves            string
+		Grid             string
+		Color            string
+		First            string
+		Variants         []game.Variant
+		VariantID        string
+		Rating           int
+		NearRatingBot    string
 		LoggedIn         bool
 		Username         string
 		Initials         string
@@ -26,6 +92,16 @@ func ShowTraining(w http.ResponseWriter, r *http.Request) {
 		HasFriendAlerts  bool
 		FriendAlertCount int
 	}{
+		Bots:             trainingBots(),
+		Error:            errorMsg,
+		Moves:            strings.TrimSpace(r.FormValue("moves")),
+		Grid:             grid,
+		Color:            r.FormValue("color"),
+		First:            r.FormValue("first"),
+		Variants:         trainingVariants(),
+		VariantID:        variantID,
+		Rating:           rating,
+		NearRatingBot:    nearRatingBot,
 		LoggedIn:         h.LoggedIn,
 		Username:         h.Username,
 		Initials:         h.Initials,
@@ -35,23 +111,8 @@ func ShowTraining(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// levelName returns a label for the bot difficulty level
-func levelName(n int) string {
-	switch n {
-	case 1:
-		return "Easy"
-	case 2:
-		return "Normal"
-	case 3:
-		return "Hard"
-	case 4:
-		return "Expert"
-	default:
-		return "Master"
-	}
-}
-
-// StartTraining creates a bot match at the chosen difficulty
+// StartTraining creates a bot match against the chosen registered bot, or a bot near the player's rating,
+// optionally in another variant, from a pasted move string or an edited grid, with the player's color and who moves first
 func StartTraining(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Redirect(w, r, "/training", http.StatusSeeOther)
@@ -68,35 +129,128 @@ func StartTraining(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	pid := getOrSetPID(w, r)
-	lv, _ := strconv.Atoi(r.FormValue("level"))
-	if lv < 1 {
-		lv = 1
+	// picks the variant, classic when missing or unknown
+	v, ok := game.LookupVariant(strings.TrimSpace(r.FormValue("variant")))
+	if !ok {
+		v = game.ClassicVariant()
+	}
+
+	// resolves the bot from the registry, targeting the player's rating in the variant's pool when asked
+	botID := strings.TrimSpace(r.FormValue("bot"))
+	if botID == nearRatingBot {
+		rating := u.Elo
+		if v.Pool != "" {
+			rating = u.Rating(v.Pool)
+		}
+		botID = game.EloBotID(rating)
+	}
+	bot, ok := game.LookupBot(botID)
+	if !ok {
+		http.Redirect(w, r, "/training", http.StatusSeeOther)
+		return
+	}
+	if v.Bot == nil || v.Bot(botID) == nil {
+		renderTraining(w, r, "Bots cannot play "+v.Name, http.StatusUnprocessableEntity)
+		return
+	}
+
+	// seats the player by the chosen color, the bot taking the other seat
+	human := game.Player1
+	switch r.FormValue("color") {
+	case "o":
+		human = game.Player2
+	case "random":
+		if rand.Intn(2) == 1 {
+			human = game.Player2
+		}
 	}
-	if lv > 5 {
-		lv = 5
+	botSeat := game.NextSeat(human, 2)
+	first := human
+	if r.FormValue("first") == "bot" {
+		first = botSeat
 	}
 
+	// sets up the start: the pasted moves played from an empty board with Player1 opening as in the move notation,
+	// or an empty board or the edited grid with first to move
+	g := game.NewGameWithRules(v.Rules)
+	moves := strings.TrimSpace(r.FormValue("moves"))
+	if moves == "" {
+		g.NextPlayer = first
+	}
+	grid := strings.TrimSpace(r.FormValue("grid"))
+	if strings.Trim(grid, ".\r\n/ ") == "" {
+		grid = ""
+	}
+	switch {
+	case moves != "" && grid != "":
+		renderTraining(w, r, "Start from either a move string or a grid, not both", http.StatusUnprocessableEntity)
+		return
+	case moves != "":
+		if !v.Rules.IsClassic() {
+			renderTraining(w, r, "Move strings are only supported on the classic board", http.StatusUnprocessableEntity)
+			return
+		}
+		cols, err := game.ParseMoves(moves)
+		if err != nil {
+			renderTraining(w, r, "Invalid move string: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		for _, c := range cols {
+			_ = game.Play(g, c)
+		}
+		if g.Over {
+			renderTraining(w, r, "The game is already over after these moves", http.StatusUnprocessableEntity)
+			return
+		}
+	case grid != "":
+		// one line per row, or rows separated by '/' as in the grid notation
+		rows := strings.Fields(strings.ReplaceAll(grid, "/", " "))
+		b, err := game.ParseGridWithRules(strings.Join(rows, "/"), v.Rules)
+		if err != nil {
+			renderTraining(w, r, "Invalid grid: "+err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if _, won := game.IsGameWon(&b); won || !game.CanMove(&b, first) {
+			renderTraining(w, r, "The game is already over in this grid", http.StatusUnprocessableEntity)
+			return
+		}
+		if !game.CanBeNext(&b, first) {
+			renderTraining(w, r, "The disc counts of this grid do not match who moves first", http.StatusUnprocessableEntity)
+			return
+		}
+		g.Board = b
+	}
+
+	pid := getOrSetPID(w, r)
+
 	now := time.Now()
 	code := genCode()
 	rm := &Room{
 		Code:         code,
-		Game:         game.NewGame(),
-		Player1ID:    pid,
-		Player2ID:    "BOT",
-		Player1User:  u.Username,
-		Player2User:  "",
+		Game:         g,
+		Variant:      v.ID,
 		CreatedAt:    now,
 		Rev:          1,
 		subs:         make(map[chan struct{}]struct{}),
 		Random:       false,
 		TurnDeadline: now.Add(2 * time.Minute),
-		StartNext:    game.Player2,
+		StartNext:    game.NextSeat(first, 2),
 		Bot:          true,
-		BotLevel:     lv,
+		BotID:        botID,
+	}
+	if human == game.Player1 {
+		rm.Player1ID, rm.Player2ID = pid, "BOT"
+		rm.Player1User = u.Username
+		g.Player1Name, g.Player2Name = u.Username, "Bot "+bot.Name()
+	} else {
+		rm.Player1ID, rm.Player2ID = "BOT", pid
+		rm.Player2User = u.Username
+		g.Player1Name, g.Player2Name = "Bot "+bot.Name(), u.Username
+	}
+	// custom starts are drills, every rematch replays them
+	if moves != "" || grid != "" {
+		rm.Start = game.Clone(g)
 	}
-	rm.Game.Player1Name = u.Username
-	rm.Game.Player2Name = "Bot " + levelName(lv)
 
 	roomsMu.Lock()
 	rooms[code] = rm
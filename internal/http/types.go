@@ -1,6 +1,10 @@
 package httphandler
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"runtime"
 	"sync"
 	"time"
 
@@ -11,21 +15,35 @@ import (
 type Room struct {
 	Code         string                     // unique room code
 	Game         *game.Game                 // game state
+	Variant      string                     // registry id of the variant played
 	Player1ID    string                     // pid for player 1
 	Player2ID    string                     // pid for player 2
+	Player3ID    string                     // pid for player 3 in free-for-all rooms
+	Player4ID    string                     // pid for player 4 in free-for-all rooms
 	Player1User  string                     // username for player 1
 	Player2User  string                     // username for player 2
+	Player3User  string                     // username for player 3
+	Player4User  string                     // username for player 4
 	CreatedAt    time.Time                  // room creation time
 	Rev          int                        // revision counter for client sync
 	subs         map[chan struct{}]struct{} // subscribers for long polling
 	Random       bool                       // whether the room is from random matchmaking
 	RematchP1    bool                       // player 1 rematch consent
 	RematchP2    bool                       // player 2 rematch consent
+	RematchP3    bool                       // player 3 rematch consent
+	RematchP4    bool                       // player 4 rematch consent
 	Forfeit      string                     // reason if ended by forfeit
 	TurnDeadline time.Time                  // deadline for the current turn
 	StartNext    game.Cell                  // who starts the next game on rematch
 	Bot          bool                       // whether this is a bot match
-	BotLevel     int                        // bot difficulty level
+	BotID        string                     // registry id of the bot engine
+	BotThinking  bool                       // whether a bot search is running for this room
+	Unrated      bool                       // whether results leave ratings untouched, the default for power-up rooms
+	Hints        int                        // hints asked in the current bot game, marking it assisted
+	Undos        int                        // takebacks in the current bot game, marking it assisted
+	Hint         *game.Hint                 // last hint given, shown while Rev is still HintRev
+	HintRev      int                        // revision the last hint was given at
+	Start        *game.Game                 // custom start position of a bot game, replayed on rematch, nil for an empty board
 }
 
 var (
@@ -37,11 +55,51 @@ var (
 // SetUserStore sets the global user store reference
 func SetUserStore(s *auth.Store) { userStore = s }
 
+// botSearches holds a slot per engine search running inside a request, so concurrent bot games cannot take every core
+var botSearches = make(chan struct{}, max(1, runtime.NumCPU()/2))
+
+// SetBotSearchLimit sets how many engine searches requests may run at the same time, called before serving
+func SetBotSearchLimit(n int) { botSearches = make(chan struct{}, max(1, n)) }
+
+// acquireSearch waits for an engine search slot, reporting false when ctx ends first
+func acquireSearch(ctx context.Context) bool {
+	select {
+	case botSearches <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseSearch frees the slot taken by acquireSearch
+func releaseSearch() { <-botSearches }
+
+// searchWait is how long an analysis, review or puzzle request waits for a search slot before being turned away
+const searchWait = 5 * time.Second
+
+// errEngineBusy reports that no search slot freed up within searchWait
+var errEngineBusy = errors.New("the engine is busy, try again shortly")
+
+// acquireRequestSearch waits up to searchWait for a search slot for r, to be freed with releaseSearch
+func acquireRequestSearch(r *http.Request) bool {
+	ctx, cancel := context.WithTimeout(r.Context(), searchWait)
+	defer cancel()
+	return acquireSearch(ctx)
+}
+
+// SetBotThinkTime sets how long the Master bot searches for a move
+func SetBotThinkTime(d time.Duration) {
+	if d > 0 {
+		game.RegisterBot("master", game.BookBot{Bot: game.SearchBot{Label: "Master", Budget: d}})
+	}
+}
+
 type waiter struct {
 	Ticket   string      // matchmaking ticket id
 	PID      string      // player id cookie
 	Username string      // username of the player
-	Elo      int         // current elo used for range matching
+	Variant  string      // registry id of the variant queued for, the queue being split by variant
+	Elo      int         // current elo in the variant's rating pool used for range matching
 	Ch       chan string // channel receiving room code when matched
 	Created  time.Time   // when the player entered the queue
 }